@@ -0,0 +1,203 @@
+package soda
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parameterBinder binds the parameters of a type, it is compiled when the operation is registered
+// so that requests are bound without looking up tags and parameters.
+type parameterBinder struct {
+	typ reflect.Type
+	// key stores the bound parameters in the Locals of the request.
+	key    string
+	fields []*boundParameter
+}
+
+// boundParameter is a field bound to a parameter.
+type boundParameter struct {
+	// index of the field, through embedded structs.
+	index   []int
+	name    string
+	in      string
+	style   string
+	explode bool
+	shape   int
+	// props are the properties of struct parameters.
+	props []boundProp
+	// defaults are bound when the parameter is absent.
+	defaults []string
+}
+
+type boundProp struct {
+	name  string
+	index []int
+}
+
+func compileParameterBinder(t reflect.Type, key string, params openapi3.Parameters) *parameterBinder {
+	b := &parameterBinder{typ: t, key: key}
+	if t.Kind() == reflect.Struct {
+		b.compile(t, nil, params)
+	}
+	return b
+}
+
+func (b *parameterBinder) compile(t reflect.Type, index []int, params openapi3.Parameters) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		field := newFieldResolver(&f)
+		fieldIndex := append(append([]int(nil), index...), i)
+		if field.shouldEmbed() {
+			if ft := indirectType(f.Type); ft.Kind() == reflect.Struct {
+				b.compile(ft, fieldIndex, params)
+			}
+			continue
+		}
+		in := parameterLocation(&f)
+		if field.ignored || in == "" {
+			continue
+		}
+		param := params.GetByInAndName(in, field.name(in))
+		if param == nil {
+			continue
+		}
+		// serialization methods are checked when the parameters are generated
+		sm, err := param.SerializationMethod()
+		if err != nil {
+			continue
+		}
+		p := &boundParameter{
+			index:   fieldIndex,
+			name:    param.Name,
+			in:      in,
+			style:   sm.Style,
+			explode: sm.Explode,
+			shape:   valueShape(f.Type),
+			props:   compileProps(indirectType(f.Type), in, nil),
+		}
+		if val, ok := field.tagPairs[PropDefault]; ok && p.shape != shapeObject {
+			p.defaults = defaultValues(f.Type, val)
+		}
+		b.fields = append(b.fields, p)
+	}
+}
+
+// compileProps returns the properties of struct t named after tag, it returns nil for other types.
+func compileProps(t reflect.Type, tag string, index []int) []boundProp {
+	if t.Kind() != reflect.Struct || valueShape(t) != shapeObject {
+		return nil
+	}
+	props := make([]boundProp, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		field := newFieldResolver(&f)
+		fieldIndex := append(append([]int(nil), index...), i)
+		if field.shouldEmbed() {
+			props = append(props, compileProps(indirectType(f.Type), tag, fieldIndex)...)
+			continue
+		}
+		if !field.ignored {
+			props = append(props, boundProp{name: field.name(tag), index: fieldIndex})
+		}
+	}
+	return props
+}
+
+// fieldDefault is the default value of a field of a request body.
+type fieldDefault struct {
+	index  []int
+	values []string
+	// array defaults are set after decoding, see applyArrayDefaults.
+	array bool
+}
+
+// compileDefaults returns the default values of the properties of struct t named after nameTag and of its nested structs,
+// the properties of nested struct pointers are not included so that absent pointers stay nil.
+func compileDefaults(t reflect.Type, nameTag string, index []int) []fieldDefault {
+	if t.Kind() != reflect.Struct || valueShape(t) != shapeObject {
+		return nil
+	}
+	var defaults []fieldDefault
+	for _, f := range structFields(t, nameTag) {
+		f := f
+		field := newFieldResolver(&f.StructField)
+		fieldIndex := append(append([]int(nil), index...), f.Index...)
+		if val, ok := field.tagPairs[PropDefault]; ok && valueShape(f.Type) != shapeObject {
+			defaults = append(defaults, fieldDefault{
+				index:  fieldIndex,
+				values: defaultValues(f.Type, val),
+				array:  valueShape(f.Type) == shapeArray,
+			})
+			continue
+		}
+		defaults = append(defaults, compileDefaults(f.Type, nameTag, fieldIndex)...)
+	}
+	return defaults
+}
+
+// prefillDefaults sets the default values into the fields of struct v before the body is decoded into it,
+// so that the fields present in the body, even with a zero value, replace them.
+// Array defaults are left to applyArrayDefaults.
+func prefillDefaults(v reflect.Value, defaults []fieldDefault) {
+	for _, d := range defaults {
+		if !d.array {
+			// defaults are checked when the schema is generated
+			_ = setValues(fieldByIndex(v, d.index), d.values)
+		}
+	}
+}
+
+// applyArrayDefaults sets the array defaults into the fields of the decoded struct v which are still nil,
+// decoders such as encoding/xml append to slices, so they are not set before decoding.
+// A present empty array, such as [] in JSON, is kept.
+func applyArrayDefaults(v reflect.Value, defaults []fieldDefault) {
+	for _, d := range defaults {
+		if field := fieldByIndex(v, d.index); d.array && field.IsZero() {
+			_ = setValues(field, d.values)
+		}
+	}
+}
+
+// defaultValues splits the default prop of a field of type t, items of arrays are separated by spaces.
+func defaultValues(t reflect.Type, val string) []string {
+	if valueShape(t) == shapeArray {
+		return strings.Split(val, " ")
+	}
+	return []string{val}
+}
+
+// bind decodes the parameters of the request into a new value of the binder's type.
+func (b *parameterBinder) bind(c *fiber.Ctx) (interface{}, ValidationErrors) {
+	out := reflect.New(b.typ)
+	var errs ValidationErrors
+	for _, p := range b.fields {
+		if err := p.decode(c, fieldByIndex(out.Elem(), p.index)); err != nil {
+			errs = append(errs, p.convertError(err))
+		}
+	}
+	return out.Interface(), errs
+}
+
+// fieldByIndex returns the nested field of v, allocating the nil embedded pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,317 @@
+package soda
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
+)
+
+type benchParameters struct {
+	ID     int      `path:"id"`
+	Page   int      `query:"page"`
+	Size   int      `query:"size"`
+	Sort   string   `query:"sort"`
+	Tags   []string `query:"tags" oai:"explode=false"`
+	Token  string   `header:"X-Token"`
+	Trace  string   `header:"X-Trace"`
+	Locale string   `cookie:"locale"`
+}
+
+const benchTarget = "/users/42?page=2&size=20&sort=name&tags=a,b,c"
+
+type parserFunc func(*fiber.Ctx, interface{}) error
+
+// legacyParameterParsers are the parsers which bound parameters before binders were compiled,
+// they are kept to benchmark the binders against them.
+var legacyParameterParsers = map[string]parserFunc{
+	"query": func(c *fiber.Ctx, out interface{}) error {
+		data := make(map[string][]string)
+		c.Request().URI().QueryArgs().VisitAll(func(key, val []byte) {
+			k := utils.UnsafeString(key)
+			data[k] = append(data[k], strings.Split(utils.UnsafeString(val), ",")...)
+		})
+		return mapToStruct("query", out, data)
+	},
+	"header": func(c *fiber.Ctx, out interface{}) error {
+		data := make(map[string][]string)
+		c.Request().Header.VisitAll(func(key, val []byte) {
+			k := utils.UnsafeString(key)
+			data[k] = append(data[k], strings.Split(utils.UnsafeString(val), ",")...)
+		})
+		return mapToStruct("header", out, data)
+	},
+	"path": func(c *fiber.Ctx, out interface{}) error {
+		data := make(map[string][]string)
+		for _, k := range c.Route().Params {
+			data[k] = []string{c.Params(k)}
+		}
+		return mapToStruct("path", out, data)
+	},
+	"cookie": func(c *fiber.Ctx, out interface{}) error {
+		data := make(map[string][]string)
+		c.Request().Header.VisitAllCookie(func(key, val []byte) {
+			k := utils.UnsafeString(key)
+			data[k] = append(data[k], strings.Split(utils.UnsafeString(val), ",")...)
+		})
+		return mapToStruct("cookie", out, data)
+	},
+}
+
+// legacyBind binds the parameters of op like requests were bound before binders were compiled.
+func legacyBind(c *fiber.Ctx, op *Operation) (interface{}, error) {
+	parameters := reflect.New(op.TParameters).Interface()
+	set := make(map[string]struct{})
+	for _, p := range op.Operation.Parameters {
+		set[p.Value.In] = struct{}{}
+	}
+	for in := range set {
+		if err := legacyParameterParsers[in](c, parameters); err != nil {
+			return nil, err
+		}
+	}
+	return parameters, nil
+}
+
+// runInRequest calls fn with the context of a request to benchTarget routed to /users/:id.
+func runInRequest(fn func(c *fiber.Ctx)) {
+	app := fiber.New()
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		fn(c)
+		return nil
+	})
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI(benchTarget)
+	ctx.Request.Header.Set("X-Token", "secret")
+	ctx.Request.Header.Set("X-Trace", "a1b2c3")
+	ctx.Request.Header.Set("Accept", "application/json, text/plain")
+	ctx.Request.Header.SetCookie("locale", "en")
+	app.Handler()(ctx)
+}
+
+func benchOperation(tb testing.TB) *Operation {
+	app := New("bench", "1.0.0")
+	op := app.Get("/users/:id", func(c *fiber.Ctx) error { return nil }).SetParameters(benchParameters{})
+	if err := op.Register(); err != nil {
+		tb.Fatal(err)
+	}
+	return op
+}
+
+func TestBinderMatchesLegacyBinding(t *testing.T) {
+	op := benchOperation(t)
+	want := &benchParameters{ID: 42, Page: 2, Size: 20, Sort: "name", Tags: []string{"a", "b", "c"}, Token: "secret", Trace: "a1b2c3", Locale: "en"}
+	runInRequest(func(c *fiber.Ctx) {
+		got, errs := op.binders[0].bind(c)
+		if len(errs) > 0 || !reflect.DeepEqual(got, want) {
+			t.Errorf("bind = %+v %v, want %+v", got, errs, want)
+		}
+		legacy, err := legacyBind(c, op)
+		if err != nil || !reflect.DeepEqual(legacy, want) {
+			t.Errorf("legacyBind = %+v %v, want %+v", legacy, err, want)
+		}
+	})
+}
+
+func TestBinderKeepsCommasOfStrings(t *testing.T) {
+	type parameters struct {
+		Filter string   `query:"filter"`
+		Accept string   `header:"Accept"`
+		IDs    []string `query:"ids" oai:"explode=false"`
+	}
+	app := New("test", "1.0.0")
+	mustRegister(t, app.Get("/items", func(c *fiber.Ctx) error {
+		return c.JSON(c.Locals(KeyParameter))
+	}).SetParameters(parameters{}))
+	resp := doRequest(t, app, "GET", "/items?filter=a,b&ids=1,2", "", "Accept", "text/html, */*")
+	if want := `{"Filter":"a,b","Accept":"text/html, */*","IDs":["1","2"]}`; resp.body != want {
+		t.Errorf("body = %s, want %s", resp.body, want)
+	}
+}
+
+func TestBinderMatchesHeadersCaseInsensitively(t *testing.T) {
+	type parameters struct {
+		Token string `header:"x-api-token"`
+	}
+	for _, disable := range []bool{false, true} {
+		app := New("test", "1.0.0")
+		app.App = fiber.New(fiber.Config{DisableHeaderNormalizing: disable})
+		mustRegister(t, app.Get("/items", func(c *fiber.Ctx) error {
+			return c.SendString(c.Locals(KeyParameter).(*parameters).Token)
+		}).SetParameters(parameters{}))
+		if resp := doRequest(t, app, "GET", "/items", "", "X-API-TOKEN", "secret"); resp.body != "secret" {
+			t.Errorf("DisableHeaderNormalizing=%v: token = %q", disable, resp.body)
+		}
+	}
+}
+
+func TestCompileParameterBinder(t *testing.T) {
+	op := benchOperation(t)
+	b := compileParameterBinder(reflect.TypeOf(benchParameters{}), KeyParameter, op.Operation.Parameters)
+	tests := []struct {
+		name    string
+		in      string
+		style   string
+		explode bool
+		shape   int
+	}{
+		{"id", "path", "simple", false, shapePrimitive},
+		{"page", "query", "form", true, shapePrimitive},
+		{"size", "query", "form", true, shapePrimitive},
+		{"sort", "query", "form", true, shapePrimitive},
+		{"tags", "query", "form", false, shapeArray},
+		{"X-Token", "header", "simple", false, shapePrimitive},
+		{"X-Trace", "header", "simple", false, shapePrimitive},
+		{"locale", "cookie", "form", true, shapePrimitive},
+	}
+	if len(b.fields) != len(tests) {
+		t.Fatalf("%d fields are bound, want %d", len(b.fields), len(tests))
+	}
+	for i, tt := range tests {
+		p := b.fields[i]
+		if p.name != tt.name || p.in != tt.in || p.style != tt.style || p.explode != tt.explode || p.shape != tt.shape {
+			t.Errorf("field %d = %+v, want %+v", i, *p, tt)
+		}
+	}
+}
+
+func BenchmarkLegacyBinding(b *testing.B) {
+	op := benchOperation(b)
+	runInRequest(func(c *fiber.Ctx) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := legacyBind(c, op); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkCompiledBinder(b *testing.B) {
+	op := benchOperation(b)
+	runInRequest(func(c *fiber.Ctx) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, binder := range op.binders {
+				if _, errs := binder.bind(c); len(errs) > 0 {
+					b.Fatal(errs)
+				}
+			}
+		}
+	})
+}
+
+type defaultParameters struct {
+	Limit int      `query:"limit" oai:"default=20"`
+	Sort  []string `query:"sort" oai:"default=name createdAt"`
+	Lang  string   `header:"X-Lang" oai:"default=en"`
+	Page  *int     `query:"page"`
+}
+
+type defaultOptions struct {
+	Color string `json:"color" xml:"color" form:"color" oai:"default=red"`
+}
+
+type defaultBody struct {
+	Name    string          `json:"name" xml:"name" form:"name" oai:"default=anonymous"`
+	Tags    []string        `json:"tags" xml:"tag" form:"tags" oai:"default=a b"`
+	Count   int             `json:"count" xml:"count" form:"count" oai:"default=3"`
+	Secret  string          `json:"-" xml:"secret" form:"-" oai:"default=hidden"`
+	Options defaultOptions  `json:"options" xml:"options" form:"options"`
+	Extra   *defaultOptions `json:"extra" xml:"extra" form:"-"`
+	Enabled bool            `json:"enabled" xml:"enabled" form:"enabled" oai:"default=true"`
+}
+
+func TestParameterDefaults(t *testing.T) {
+	app := New("test", "1.0.0")
+	mustRegister(t, app.Get("/items", func(c *fiber.Ctx) error {
+		return c.JSON(c.Locals(KeyParameter))
+	}).SetParameters(defaultParameters{}))
+	tests := []struct {
+		target  string
+		headers []string
+		want    string
+	}{
+		{"/items", nil, `{"Limit":20,"Sort":["name","createdAt"],"Lang":"en","Page":null}`},
+		{"/items?limit=5&sort=id&page=2", []string{"X-Lang", "fr"}, `{"Limit":5,"Sort":["id"],"Lang":"fr","Page":2}`},
+	}
+	for _, tt := range tests {
+		if resp := doRequest(t, app, "GET", tt.target, "", tt.headers...); resp.body != tt.want {
+			t.Errorf("GET %s = %s, want %s", tt.target, resp.body, tt.want)
+		}
+	}
+}
+
+func TestBodyDefaults(t *testing.T) {
+	app := New("test", "1.0.0")
+	mustRegister(t, app.Post("/items", func(c *fiber.Ctx) error {
+		body := c.Locals(KeyRequestBody).(*defaultBody)
+		return c.JSON(map[string]interface{}{"body": body, "secret": body.Secret})
+	}).SetRequestBody(defaultBody{}, fiber.MIMEApplicationJSON, fiber.MIMEApplicationXML, fiber.MIMEApplicationForm))
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"json absent", fiber.MIMEApplicationJSON, `{}`,
+			`{"body":{"name":"anonymous","tags":["a","b"],"count":3,"options":{"color":"red"},"extra":null,"enabled":true},"secret":""}`},
+		{"json present", fiber.MIMEApplicationJSON, `{"name":"neo","tags":["x"],"count":1,"options":{"color":"blue"},"extra":{}}`,
+			`{"body":{"name":"neo","tags":["x"],"count":1,"options":{"color":"blue"},"extra":{"color":""},"enabled":true},"secret":""}`},
+		// zero values which are present are not replaced by defaults
+		{"json zero values", fiber.MIMEApplicationJSON, `{"name":"","tags":[],"count":0,"options":{"color":""},"enabled":false}`,
+			`{"body":{"name":"","tags":[],"count":0,"options":{"color":""},"extra":null,"enabled":false},"secret":""}`},
+		{"xml absent", fiber.MIMEApplicationXML, `<defaultBody></defaultBody>`,
+			`{"body":{"name":"anonymous","tags":["a","b"],"count":3,"options":{"color":"red"},"extra":null,"enabled":true},"secret":"hidden"}`},
+		// encoding/xml appends to slices, defaults must not be decoded over
+		{"xml present", fiber.MIMEApplicationXML, `<defaultBody><tag>x</tag><tag>y</tag><secret>s</secret></defaultBody>`,
+			`{"body":{"name":"anonymous","tags":["x","y"],"count":3,"options":{"color":"red"},"extra":null,"enabled":true},"secret":"s"}`},
+		{"xml zero values", fiber.MIMEApplicationXML, `<defaultBody><name></name><count>0</count><enabled>false</enabled></defaultBody>`,
+			`{"body":{"name":"","tags":["a","b"],"count":0,"options":{"color":"red"},"extra":null,"enabled":false},"secret":"hidden"}`},
+		{"form absent", fiber.MIMEApplicationForm, ``,
+			`{"body":{"name":"anonymous","tags":["a","b"],"count":3,"options":{"color":"red"},"extra":null,"enabled":true},"secret":""}`},
+		{"form zero values", fiber.MIMEApplicationForm, `count=0&enabled=false&options.color=blue`,
+			`{"body":{"name":"anonymous","tags":["a","b"],"count":0,"options":{"color":"blue"},"extra":null,"enabled":false},"secret":""}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, app, "POST", "/items", tt.body, fiber.HeaderContentType, tt.contentType)
+			if resp.body != tt.want {
+				t.Errorf("body = %s, want %s", resp.body, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileDefaults(t *testing.T) {
+	tests := []struct {
+		nameTag string
+		want    []fieldDefault
+	}{
+		{"json", []fieldDefault{
+			{index: []int{0}, values: []string{"anonymous"}},
+			{index: []int{1}, values: []string{"a", "b"}, array: true},
+			{index: []int{2}, values: []string{"3"}},
+			{index: []int{4, 0}, values: []string{"red"}},
+			{index: []int{6}, values: []string{"true"}},
+		}},
+		{"xml", []fieldDefault{
+			{index: []int{0}, values: []string{"anonymous"}},
+			{index: []int{1}, values: []string{"a", "b"}, array: true},
+			{index: []int{2}, values: []string{"3"}},
+			{index: []int{3}, values: []string{"hidden"}},
+			{index: []int{4, 0}, values: []string{"red"}},
+			{index: []int{6}, values: []string{"true"}},
+		}},
+	}
+	for _, tt := range tests {
+		if got := compileDefaults(reflect.TypeOf(defaultBody{}), tt.nameTag, nil); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("compileDefaults(%s) = %+v, want %+v", tt.nameTag, got, tt.want)
+		}
+	}
+}
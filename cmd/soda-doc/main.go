@@ -0,0 +1,219 @@
+// Command soda-doc extracts the doc comments of the struct types, fields and functions of a package
+// into a generated file which registers them by soda.RegisterDescriptions, so that they describe
+// the schemas, parameters and operations generated by soda.
+//
+// It is meant to be run by go generate, in the directory of the package:
+//
+//	//go:generate go run github.com/captain-neo/soda/cmd/soda-doc
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("soda-doc: ")
+	output := flag.String("output", "soda_descriptions.go", "name of the generated file")
+	importPath := flag.String("pkg", "", "import path of the package, found by go list by default")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	name, docs, err := extract(dir, filepath.Base(*output))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	// the runtime names the declarations of main packages after "main"
+	if name == "main" {
+		*importPath = "main"
+	}
+	if *importPath == "" {
+		if *importPath, err = listImportPath(dir); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	src, err := generate(name, *importPath, docs)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, *output), src, 0o644); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func listImportPath(dir string) (string, error) {
+	cmd := exec.Command("go", "list", "-f", "{{.ImportPath}}", ".")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go list: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// extract returns the name of the package in dir and the doc comments of its declarations,
+// keyed by their names in the package, such as "User.Name". The generated file is skipped.
+func extract(dir, generated string) (string, map[string]string, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info fs.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != generated
+	}, parser.ParseComments)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("%s holds %d packages, expected one", dir, len(pkgs))
+	}
+	docs := make(map[string]string)
+	var name string
+	for pkgName, pkg := range pkgs {
+		name = pkgName
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				switch decl := decl.(type) {
+				case *ast.GenDecl:
+					extractTypes(docs, decl)
+				case *ast.FuncDecl:
+					add(docs, funcName(decl), decl.Doc)
+				}
+			}
+		}
+	}
+	return name, docs, nil
+}
+
+func extractTypes(docs map[string]string, decl *ast.GenDecl) {
+	if decl.Tok != token.TYPE {
+		return
+	}
+	for _, spec := range decl.Specs {
+		spec := spec.(*ast.TypeSpec)
+		typeName := spec.Name.Name
+		doc := spec.Doc
+		// the doc comment of "type User struct" is the one of the declaration
+		if doc == nil && len(decl.Specs) == 1 {
+			doc = decl.Doc
+		}
+		add(docs, typeName, doc)
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		for _, field := range st.Fields.List {
+			doc := field.Doc
+			if doc == nil {
+				doc = field.Comment
+			}
+			for _, name := range fieldNames(field) {
+				add(docs, typeName+"."+name, doc)
+			}
+		}
+	}
+}
+
+// fieldNames returns the names of the fields declared together, embedded fields are named after their type.
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) == 0 {
+		typ := field.Type
+		for {
+			switch t := typ.(type) {
+			case *ast.StarExpr:
+				typ = t.X
+				continue
+			case *ast.SelectorExpr:
+				return []string{t.Sel.Name}
+			case *ast.IndexExpr:
+				typ = t.X
+				continue
+			case *ast.Ident:
+				return []string{t.Name}
+			}
+			return nil
+		}
+	}
+	names := make([]string, 0, len(field.Names))
+	for _, name := range field.Names {
+		names = append(names, name.Name)
+	}
+	return names
+}
+
+// funcName names a function or a method as the runtime does, such as "getUser" or "(*Server).getUser".
+func funcName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	typ := decl.Recv.List[0].Type
+	pointer := false
+	if star, ok := typ.(*ast.StarExpr); ok {
+		pointer = true
+		typ = star.X
+	}
+	// the type parameters of generic receivers
+	switch t := typ.(type) {
+	case *ast.IndexExpr:
+		typ = t.X
+	case *ast.IndexListExpr:
+		typ = t.X
+	}
+	recv := ""
+	if ident, ok := typ.(*ast.Ident); ok {
+		recv = ident.Name
+	}
+	if pointer {
+		return "(*" + recv + ")." + decl.Name.Name
+	}
+	return recv + "." + decl.Name.Name
+}
+
+// add registers the text of doc under name, the lines of a paragraph are joined.
+func add(docs map[string]string, name string, doc *ast.CommentGroup) {
+	if doc == nil {
+		return
+	}
+	var paragraphs []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(doc.Text()), "\n\n") {
+		if paragraph = strings.Join(strings.Fields(paragraph), " "); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	if len(paragraphs) > 0 {
+		docs[name] = strings.Join(paragraphs, "\n\n")
+	}
+}
+
+// generate writes the file registering docs, whose keys are qualified by importPath.
+func generate(pkg, importPath string, docs map[string]string) ([]byte, error) {
+	names := make([]string, 0, len(docs))
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by soda-doc. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	buf.WriteString("import \"github.com/captain-neo/soda\"\n\n")
+	buf.WriteString("func init() {\n\tsoda.RegisterDescriptions(map[string]string{\n")
+	for _, name := range names {
+		fmt.Fprintf(&buf, "\t\t%s: %s,\n", strconv.Quote(importPath+"."+name), strconv.Quote(docs[name]))
+	}
+	buf.WriteString("\t})\n}\n")
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const source = `package api
+
+// User is a user.
+type User struct {
+	Base
+	*Audit
+	// Name is the full name.
+	Name string
+	// Email and Phone are the contacts.
+	Email, Phone string
+	Age int // Age is in years.
+	Page[User]
+	secret string
+}
+
+type (
+	// Base holds the common fields.
+	Base struct{}
+	// Audit
+	// is the history.
+	//
+	// It is optional.
+	Audit struct{}
+)
+
+// Page is a page of items.
+type Page[T any] struct{}
+
+// Server serves the API.
+type Server struct{}
+
+// getUser returns a user.
+func (s *Server) getUser() {}
+
+// list lists the pages.
+func (p Page[T]) list() {}
+
+// healthz answers.
+func healthz() {}
+
+func undocumented() {}
+`
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"api.go":               source,
+		"api_test.go":          "package api\n\n// Fixture is skipped.\ntype Fixture struct{}\n",
+		"soda_descriptions.go": "package api\n\n// Generated is skipped.\ntype Generated struct{}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	name, docs, err := extract(dir, "soda_descriptions.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "api" {
+		t.Errorf("package = %q", name)
+	}
+	want := map[string]string{
+		"User":              "User is a user.",
+		"User.Name":         "Name is the full name.",
+		"User.Email":        "Email and Phone are the contacts.",
+		"User.Phone":        "Email and Phone are the contacts.",
+		"User.Age":          "Age is in years.",
+		"Base":              "Base holds the common fields.",
+		"Audit":             "Audit is the history.\n\nIt is optional.",
+		"Page":              "Page is a page of items.",
+		"Server":            "Server serves the API.",
+		"(*Server).getUser": "getUser returns a user.",
+		"Page.list":         "list lists the pages.",
+		"healthz":           "healthz answers.",
+	}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("docs = %q, want %q", docs, want)
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"several packages", map[string]string{"a.go": "package a\n", "b.go": "package b\n"}, "holds 2 packages"},
+		{"no package", map[string]string{}, "holds 0 packages"},
+		{"syntax error", map[string]string{"a.go": "package a\n\nfunc {"}, "expected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, _, err := extract(dir, "soda_descriptions.go"); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := generate("api", "github.com/acme/api", map[string]string{
+		"User.Name": "Name is the \"full\" name.",
+		"User":      "User is a user.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// Code generated by soda-doc. DO NOT EDIT.
+
+package api
+
+import "github.com/captain-neo/soda"
+
+func init() {
+	soda.RegisterDescriptions(map[string]string{
+		"github.com/acme/api.User":      "User is a user.",
+		"github.com/acme/api.User.Name": "Name is the \"full\" name.",
+	})
+}
+`
+	if string(src) != want {
+		t.Errorf("generated:\n%s\nwant:\n%s", src, want)
+	}
+}
@@ -0,0 +1,175 @@
+package soda
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const MIMEApplicationMsgPack = "application/msgpack"
+
+// Codec encodes and decodes the bodies of a content type.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	// NameTag is the struct tag naming the properties of the encoded bodies.
+	NameTag() string
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) NameTag() string                            { return "json" }
+
+type xmlCodec struct{}
+
+func (xmlCodec) Marshal(v interface{}) ([]byte, error)      { return xml.Marshal(v) }
+func (xmlCodec) Unmarshal(data []byte, v interface{}) error { return xml.Unmarshal(data, v) }
+func (xmlCodec) NameTag() string                            { return "xml" }
+
+// msgpackCodec names the properties after the json tag, so that a model is shared with JSON bodies.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+func (msgpackCodec) NameTag() string { return "json" }
+
+func defaultCodecs() map[string]Codec {
+	return map[string]Codec{
+		fiber.MIMEApplicationJSON: jsonCodec{},
+		fiber.MIMEApplicationXML:  xmlCodec{},
+		MIMEApplicationMsgPack:    msgpackCodec{},
+	}
+}
+
+// mediaType strips the parameters, such as the charset, of a Content-Type.
+func mediaType(contentType string) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mt
+	}
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+// nameTags maps each of contentTypes to the tag naming the properties of its bodies,
+// content types without a codec are reported as errors of the operation.
+func (op *Operation) nameTags(contentTypes []string) map[string]string {
+	tags := make(map[string]string, len(contentTypes))
+	for _, ct := range contentTypes {
+		switch ct {
+		case fiber.MIMEApplicationForm, fiber.MIMEMultipartForm:
+			tags[ct] = "form"
+		default:
+			codec, ok := op.Soda.Options.codecs[ct]
+			if !ok {
+				op.addError("", "no codec is registered for content type "+ct)
+				continue
+			}
+			tags[ct] = codec.NameTag()
+		}
+	}
+	return tags
+}
+
+// parseBody decodes the request body according to its Content-Type,
+// content types which are not declared by the operation are answered with 415.
+func (op *Operation) parseBody(c *fiber.Ctx, out interface{}) error {
+	ct := mediaType(utils.UnsafeString(c.Request().Header.ContentType()))
+	if _, ok := op.Operation.RequestBody.Value.Content[ct]; !ok {
+		return fiber.ErrUnsupportedMediaType
+	}
+	switch ct {
+	case fiber.MIMEApplicationForm:
+		return formParser(c, out)
+	case fiber.MIMEMultipartForm:
+		return multipartParser(c, out)
+	}
+	codec, ok := op.Soda.Options.codecs[ct]
+	if !ok {
+		return fiber.ErrUnsupportedMediaType
+	}
+	if op.bodyOneOf && ct == fiber.MIMEApplicationJSON {
+		return unmarshalOneOfs(codec, c.Body(), out)
+	}
+	return codec.Unmarshal(c.Body(), out)
+}
+
+// bodyNameTag returns the tag naming the properties of the request body.
+func (op *Operation) bodyNameTag(c *fiber.Ctx) string {
+	return op.contentNameTag(mediaType(utils.UnsafeString(c.Request().Header.ContentType())))
+}
+
+// contentNameTag returns the tag naming the properties of the bodies of content type ct, json by default.
+func (op *Operation) contentNameTag(ct string) string {
+	switch ct {
+	case fiber.MIMEApplicationForm, fiber.MIMEMultipartForm:
+		return "form"
+	}
+	if codec, ok := op.Soda.Options.codecs[ct]; ok {
+		return codec.NameTag()
+	}
+	return "json"
+}
+
+// Respond sends v with status, encoded in the content type documented for status which the Accept header prefers.
+// Requests accepting none of them are answered with 406.
+// It is meant for handlers of operations registered by soda.
+func Respond(c *fiber.Ctx, status int, v interface{}) error {
+	op, ok := c.Locals(keyOperation).(*Operation)
+	if !ok {
+		return c.Status(status).JSON(v)
+	}
+	var offers []string
+	if response := op.Operation.Responses.Get(status); response != nil && response.Value != nil {
+		for ct := range response.Value.Content {
+			if _, ok := op.Soda.Options.codecs[ct]; ok {
+				offers = append(offers, ct)
+			}
+		}
+	}
+	if len(offers) == 0 {
+		return c.Status(status).JSON(v)
+	}
+	sortOffers(offers)
+	ct := c.Accepts(offers...)
+	if ct == "" {
+		return fiber.ErrNotAcceptable
+	}
+	data, err := op.Soda.Options.codecs[ct].Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, ct)
+	return c.Status(status).Send(data)
+}
+
+// sortOffers sorts content types so that requests without preference get JSON, or the first of the others alphabetically.
+func sortOffers(offers []string) {
+	sort.Slice(offers, func(i, j int) bool {
+		if offers[i] == fiber.MIMEApplicationJSON || offers[j] == fiber.MIMEApplicationJSON {
+			return offers[i] == fiber.MIMEApplicationJSON
+		}
+		return offers[i] < offers[j]
+	})
+}
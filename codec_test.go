@@ -0,0 +1,135 @@
+package soda
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type codecUser struct {
+	ID   int    `json:"id" xml:"identifier"`
+	Name string `json:"name" xml:"full_name"`
+}
+
+// csvCodec encodes a user as "id,name", it is only used by tests.
+type csvCodec struct{}
+
+func (csvCodec) Marshal(v interface{}) ([]byte, error) {
+	u := v.(*codecUser)
+	return []byte(strconv.Itoa(u.ID) + "," + u.Name), nil
+}
+
+func (csvCodec) Unmarshal(data []byte, v interface{}) error {
+	parts := strings.SplitN(string(data), ",", 2)
+	u := v.(*codecUser)
+	u.ID, u.Name = toInt(parts[0]), parts[1]
+	return nil
+}
+
+func (csvCodec) NameTag() string { return "json" }
+
+func TestCodecs(t *testing.T) {
+	user := &codecUser{ID: 1, Name: "neo"}
+	for contentType, codec := range defaultCodecs() {
+		data, err := codec.Marshal(user)
+		if err != nil {
+			t.Fatalf("%s: %v", contentType, err)
+		}
+		var got codecUser
+		if err := codec.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: %v", contentType, err)
+		}
+		if got != *user {
+			t.Errorf("%s: round trip = %+v", contentType, got)
+		}
+	}
+	// msgpack shares the property names of JSON
+	data, _ := msgpackCodec{}.Marshal(user)
+	var fields map[string]interface{}
+	if err := (msgpackCodec{}).Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields["name"]; !ok {
+		t.Errorf("msgpack fields = %v", fields)
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	app := New("test", "1.0.0", WithCodec("text/csv", csvCodec{}))
+	op := mustRegister(t, app.Post("/users", func(c *fiber.Ctx) error {
+		return Respond(c, fiber.StatusCreated, c.Locals(KeyRequestBody))
+	}).
+		SetRequestBody(codecUser{}, fiber.MIMEApplicationJSON, fiber.MIMEApplicationXML, "text/csv").
+		AddResponse(fiber.StatusCreated, codecUser{}, fiber.MIMEApplicationJSON, fiber.MIMEApplicationXML, "text/csv"))
+
+	tests := []struct {
+		name                string
+		contentType, accept string
+		body                string
+		status              int
+		responseType        string
+		want                string
+	}{
+		{"json", fiber.MIMEApplicationJSON, "", `{"id":1,"name":"neo"}`, 201, fiber.MIMEApplicationJSON, `{"id":1,"name":"neo"}`},
+		{"charset", fiber.MIMEApplicationJSONCharsetUTF8, "*/*", `{"id":1,"name":"neo"}`, 201, fiber.MIMEApplicationJSON, `{"id":1,"name":"neo"}`},
+		{"xml", fiber.MIMEApplicationXML, fiber.MIMEApplicationXML,
+			`<codecUser><identifier>1</identifier><full_name>neo</full_name></codecUser>`, 201, fiber.MIMEApplicationXML,
+			`<codecUser><identifier>1</identifier><full_name>neo</full_name></codecUser>`},
+		{"custom codec", "text/csv", "text/csv", `1,neo`, 201, "text/csv", `1,neo`},
+		{"json to xml", fiber.MIMEApplicationJSON, "application/xml;q=0.9, text/html", `{"id":1,"name":"neo"}`, 201, fiber.MIMEApplicationXML,
+			`<codecUser><identifier>1</identifier><full_name>neo</full_name></codecUser>`},
+		{"unsupported", fiber.MIMETextPlain, "", `1,neo`, 415, fiber.MIMETextPlainCharsetUTF8, "Unsupported Media Type"},
+		{"not acceptable", fiber.MIMEApplicationJSON, fiber.MIMETextHTML, `{"id":1,"name":"neo"}`, 406, fiber.MIMETextPlainCharsetUTF8, "Not Acceptable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := []string{fiber.HeaderContentType, tt.contentType}
+			if tt.accept != "" {
+				headers = append(headers, fiber.HeaderAccept, tt.accept)
+			}
+			resp := doRequest(t, app, "POST", "/users", tt.body, headers...)
+			if resp.status != tt.status || resp.body != tt.want {
+				t.Errorf("response = %d %s, want %d %s", resp.status, resp.body, tt.status, tt.want)
+			}
+			if ct := resp.header.Get(fiber.HeaderContentType); ct != tt.responseType {
+				t.Errorf("content type = %q, want %q", ct, tt.responseType)
+			}
+		})
+	}
+
+	// XML bodies are named after the xml tag
+	for _, content := range []interface{}{op.Operation.RequestBody.Value.Content, op.Operation.Responses.Get(201).Value.Content} {
+		got := toJSON(t, content)
+		for _, want := range []string{`"application/json":{"schema":{"$ref":"#/components/schemas/sodaCodecUser"}}`,
+			`"application/xml":{"schema":{"$ref":"#/components/schemas/sodaCodecUserXml"}}`,
+			`"text/csv":{"schema":{"$ref":"#/components/schemas/sodaCodecUser"}}`} {
+			if !strings.Contains(got, want) {
+				t.Errorf("content = %s, want %s", got, want)
+			}
+		}
+	}
+	xmlSchema := app.OpenAPI().Components.Schemas["sodaCodecUserXml"]
+	if xmlSchema == nil || xmlSchema.Value.Properties["full_name"] == nil {
+		t.Error("the XML schema is not named after the xml tag")
+	}
+}
+
+func TestUnknownCodec(t *testing.T) {
+	app := New("test", "1.0.0")
+	err := app.Post("/users", func(c *fiber.Ctx) error { return nil }).SetRequestBody(codecUser{}, "text/csv").Register()
+	if err == nil || !strings.Contains(err.Error(), "no codec is registered for content type text/csv") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestSortOffers(t *testing.T) {
+	offers := []string{"text/csv", fiber.MIMEApplicationXML, fiber.MIMEApplicationJSON, MIMEApplicationMsgPack}
+	sortOffers(offers)
+	want := []string{fiber.MIMEApplicationJSON, MIMEApplicationMsgPack, fiber.MIMEApplicationXML, "text/csv"}
+	if !reflect.DeepEqual(offers, want) {
+		t.Errorf("offers = %v, want %v", offers, want)
+	}
+}
@@ -2,6 +2,7 @@ package soda
 
 const (
 	OpenAPITag        = "oai"
+	ValidateTag       = "validate"
 	SeparatorProp     = ";"
 	SeparatorPropItem = ","
 )
@@ -12,6 +13,12 @@ const (
 	PropStyle   = "style"
 )
 
+// encoding props.
+const (
+	// PropContentType declares the allowed content types of a multipart part, separated by SeparatorPropItem.
+	PropContentType = "contentType"
+)
+
 // schema props.
 const (
 	// generic properties.
@@ -53,7 +60,19 @@ const (
 	TypeObject  = "object"
 )
 
+const (
+	jwtSecuritySchemeName       = "JWTAuth"
+	validationErrorResponseName = "ValidationErrors"
+	problemResponseName         = "Problem"
+)
+
+const (
+	MIMEApplicationProblemJSON = "application/problem+json"
+	MIMEApplicationYAML        = "application/yaml"
+)
+
 const (
 	KeyParameter   = "soda::parameters"
 	KeyRequestBody = "soda::request_body"
+	keyOperation   = "soda::operation"
 )
@@ -1,88 +1,490 @@
 package soda
 
 import (
+	"encoding"
+	"fmt"
+	"mime/multipart"
+	"reflect"
+	"strconv"
 	"strings"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
 	"github.com/gorilla/schema"
 )
 
-type parserFunc func(*fiber.Ctx, interface{}) error
+// parameterPositions are the locations of parameters, which are also the tags naming them.
+var parameterPositions = []string{"query", "header", "path", "cookie"}
 
-var parameterParsers = map[string]parserFunc{
-	"query":  queryParser,
-	"header": headerParser,
-	"path":   pathParser,
-	"cookie": cookieParser,
+// parameterLocation returns the location of a parameter field, which is the first of its location tags.
+func parameterLocation(f *reflect.StructField) string {
+	for _, position := range parameterPositions {
+		if name := f.Tag.Get(position); name != "" {
+			return position
+		}
+	}
+	return ""
 }
 
-func queryParser(c *fiber.Ctx, out interface{}) error {
-	data := make(map[string][]string)
-	c.Request().URI().QueryArgs().VisitAll(func(key, val []byte) {
-		k := utils.UnsafeString(key)
-		v := utils.UnsafeString(val)
-		if strings.Contains(v, ",") {
-			values := strings.Split(v, ",")
-			for i := 0; i < len(values); i++ {
-				data[k] = append(data[k], values[i])
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// shapes of parameter values, which decide how they are serialized.
+const (
+	shapePrimitive = iota
+	shapeArray
+	shapeObject
+)
+
+func valueShape(t reflect.Type) int {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if shape, ok := registeredShape(t); ok {
+		return shape
+	}
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return shapePrimitive
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return shapePrimitive
+		}
+		return shapeArray
+	case reflect.Struct, reflect.Map:
+		return shapeObject
+	}
+	return shapePrimitive
+}
+
+// checkSerializationMethod reports the serialization methods which can not serialize values of t.
+func checkSerializationMethod(sm *openapi3.SerializationMethod, t reflect.Type) error {
+	shape := valueShape(t)
+	switch sm.Style {
+	case openapi3.SerializationDeepObject:
+		if shape != shapeObject {
+			return SerializationMethodError{Style: sm.Style, Explode: sm.Explode}
+		}
+	case openapi3.SerializationSpaceDelimited, openapi3.SerializationPipeDelimited:
+		if shape == shapePrimitive || (shape == shapeObject && sm.Explode) {
+			return SerializationMethodError{Style: sm.Style, Explode: sm.Explode}
+		}
+	}
+	return nil
+}
+
+// decode extracts the parameter from the request according to its serialization method and binds it into v.
+func (p *boundParameter) decode(c *fiber.Ctx, v reflect.Value) error {
+	if p.shape == shapeObject {
+		props, found, err := p.extractProps(c)
+		if err != nil || !found {
+			return err
+		}
+		return p.setProps(v, props)
+	}
+	values, found, err := p.extractValues(c)
+	if err != nil {
+		return err
+	}
+	if !found {
+		if p.defaults == nil {
+			return nil
+		}
+		values = p.defaults
+	}
+	return setValues(v, values)
+}
+
+// extractValues returns the values of a primitive or an array parameter, found tells if the parameter is present.
+func (p *boundParameter) extractValues(c *fiber.Ctx) ([]string, bool, error) {
+	array := p.shape == shapeArray
+	if p.in == openapi3.ParameterInQuery {
+		args := c.Request().URI().QueryArgs()
+		if array && p.explode {
+			values := args.PeekMulti(p.name)
+			items := make([]string, len(values))
+			for i, value := range values {
+				items[i] = string(value)
 			}
-		} else {
-			data[k] = append(data[k], v)
+			return items, len(items) > 0, nil
 		}
-	})
-	return mapToStruct("query", out, data)
+		raw := args.Peek(p.name)
+		if raw == nil {
+			return nil, false, nil
+		}
+		if !array {
+			return []string{string(raw)}, true, nil
+		}
+		return strings.Split(string(raw), queryDelimiter(p.style)), true, nil
+	}
+	raw, found, err := p.rawValue(c)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	if !array {
+		return []string{raw}, true, nil
+	}
+	separator := ","
+	if p.in == openapi3.ParameterInPath && p.explode {
+		switch p.style {
+		case openapi3.SerializationLabel:
+			separator = "."
+		case openapi3.SerializationMatrix:
+			separator = ";" + p.name + "="
+		}
+	}
+	return strings.Split(raw, separator), true, nil
 }
 
-func headerParser(c *fiber.Ctx, out interface{}) error {
-	data := make(map[string][]string)
-	c.Request().Header.VisitAll(func(key, val []byte) {
-		k := utils.UnsafeString(key)
-		v := utils.UnsafeString(val)
-		if strings.Contains(v, ",") {
-			values := strings.Split(v, ",")
-			for i := 0; i < len(values); i++ {
-				data[k] = append(data[k], values[i])
+// extractProps returns the properties of an object parameter, found tells if the parameter is present.
+func (p *boundParameter) extractProps(c *fiber.Ctx) (map[string][]string, bool, error) {
+	if p.in == openapi3.ParameterInQuery {
+		args := c.Request().URI().QueryArgs()
+		props := make(map[string][]string)
+		switch {
+		case p.style == openapi3.SerializationDeepObject:
+			prefix := p.name + "["
+			args.VisitAll(func(key, val []byte) {
+				k := string(key)
+				if strings.HasPrefix(k, prefix) && strings.HasSuffix(k, "]") {
+					prop := k[len(prefix) : len(k)-1]
+					props[prop] = append(props[prop], string(val))
+				}
+			})
+		case p.explode && p.props != nil:
+			// the properties of structs are query parameters on their own
+			for _, prop := range p.props {
+				for _, value := range args.PeekMulti(prop.name) {
+					props[prop.name] = append(props[prop.name], string(value))
+				}
 			}
-		} else {
-			data[k] = append(data[k], v)
+		case p.explode:
+			// maps take every query parameter
+			args.VisitAll(func(key, val []byte) {
+				props[string(key)] = append(props[string(key)], string(val))
+			})
+		default:
+			raw := args.Peek(p.name)
+			if raw == nil {
+				return nil, false, nil
+			}
+			return pairsToProps(strings.Split(string(raw), queryDelimiter(p.style)))
 		}
-	})
+		return props, len(props) > 0, nil
+	}
+	raw, found, err := p.rawValue(c)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	if !p.explode || p.in == openapi3.ParameterInCookie {
+		return pairsToProps(strings.Split(raw, ","))
+	}
+	switch p.style {
+	case openapi3.SerializationLabel:
+		return assignmentsToProps(strings.Split(raw, "."))
+	case openapi3.SerializationMatrix:
+		return assignmentsToProps(strings.Split(raw, ";"))
+	}
+	return assignmentsToProps(strings.Split(raw, ","))
+}
 
-	return mapToStruct("header", out, data)
+// rawValue returns the serialized value of a header, cookie or path parameter,
+// without the prefix of label and matrix path parameters.
+func (p *boundParameter) rawValue(c *fiber.Ctx) (string, bool, error) {
+	var raw string
+	switch p.in {
+	case openapi3.ParameterInHeader:
+		raw = string(c.Request().Header.Peek(p.name))
+		// header names are only normalized, thus matched case-insensitively, by default
+		if raw == "" && c.App().Config().DisableHeaderNormalizing {
+			c.Request().Header.VisitAll(func(key, val []byte) {
+				if raw == "" && strings.EqualFold(string(key), p.name) {
+					raw = string(val)
+				}
+			})
+		}
+	case openapi3.ParameterInCookie:
+		raw = string(c.Request().Header.Cookie(p.name))
+	case openapi3.ParameterInPath:
+		raw = c.Params(p.name)
+	}
+	if raw == "" {
+		return "", false, nil
+	}
+	if p.in != openapi3.ParameterInPath {
+		return raw, true, nil
+	}
+	var prefix string
+	switch {
+	case p.style == openapi3.SerializationLabel:
+		prefix = "."
+	case p.style == openapi3.SerializationMatrix && p.explode && p.shape == shapeObject:
+		// every property is named on its own
+		prefix = ";"
+	case p.style == openapi3.SerializationMatrix:
+		prefix = ";" + p.name + "="
+	default:
+		return raw, true, nil
+	}
+	if !strings.HasPrefix(raw, prefix) {
+		return "", true, &ParseError{Kind: KindInvalidFormat, Value: raw, Reason: fmt.Sprintf("invalid value, expected the prefix %q", prefix)}
+	}
+	return strings.TrimPrefix(raw, prefix), true, nil
 }
 
-func pathParser(c *fiber.Ctx, out interface{}) error {
-	data := make(map[string][]string)
-	for _, k := range c.Route().Params {
-		data[k] = []string{c.Params(k)}
+// queryDelimiter returns the separator of the items of unexploded query parameters.
+func queryDelimiter(style string) string {
+	switch style {
+	case openapi3.SerializationSpaceDelimited:
+		return " "
+	case openapi3.SerializationPipeDelimited:
+		return "|"
+	}
+	return ","
+}
+
+// pairsToProps converts the items "k1,v1,k2,v2" of an unexploded object.
+func pairsToProps(items []string) (map[string][]string, bool, error) {
+	if len(items)%2 != 0 {
+		return nil, true, &ParseError{Kind: KindInvalidFormat, Value: strings.Join(items, ","), Reason: "invalid value, expected pairs of keys and values"}
+	}
+	props := make(map[string][]string, len(items)/2)
+	for i := 0; i < len(items); i += 2 {
+		props[items[i]] = append(props[items[i]], items[i+1])
+	}
+	return props, true, nil
+}
+
+// assignmentsToProps converts the items "k1=v1" of an exploded object.
+func assignmentsToProps(items []string) (map[string][]string, bool, error) {
+	props := make(map[string][]string, len(items))
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+		i := strings.Index(item, "=")
+		if i < 0 {
+			return nil, true, &ParseError{Kind: KindInvalidFormat, Value: item, Reason: "invalid value, expected key=value"}
+		}
+		props[item[:i]] = append(props[item[:i]], item[i+1:])
+	}
+	return props, true, nil
+}
+
+// setProps binds the properties of an object parameter into the struct or the map v.
+func (p *boundParameter) setProps(v reflect.Value, props map[string][]string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return p.setProps(v.Elem(), props)
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, prop := range p.props {
+			values, ok := props[prop.name]
+			if !ok {
+				continue
+			}
+			if err := setValues(fieldByIndex(v, prop.index), values); err != nil {
+				return prependPath(err, prop.name)
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return &ParseError{Kind: KindUnsupportedFormat, Reason: "unsupported type " + v.Type().String()}
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(props)))
+		}
+		for k, values := range props {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setValues(elem, values); err != nil {
+				return prependPath(err, k)
+			}
+			v.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), elem)
+		}
+	}
+	return nil
+}
+
+// setValues binds the values of a primitive or an array into v, primitives take the first value.
+func setValues(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := setValues(elem.Elem(), values); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if valueShape(v.Type()) != shapeArray {
+		return setValue(v, values[0])
+	}
+	if v.Kind() == reflect.Slice {
+		v.Set(reflect.MakeSlice(v.Type(), len(values), len(values)))
+	} else if len(values) > v.Len() {
+		return &ParseError{Kind: KindInvalidFormat, Value: strings.Join(values, ","), Reason: fmt.Sprintf("invalid value, expected at most %d items", v.Len())}
+	}
+	for i, value := range values {
+		if err := setValue(v.Index(i), value); err != nil {
+			return prependPath(err, i)
+		}
+	}
+	return nil
+}
+
+// setValue converts a single value into v.
+func setValue(v reflect.Value, value string) error {
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	invalid := &ParseError{Kind: KindInvalidFormat, Value: value, Reason: "invalid value, expected " + v.Type().String()}
+	if ok, err := decodeRegisteredType(v, value); ok {
+		if err != nil {
+			invalid.Cause = err
+			return invalid
+		}
+		return nil
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(value)); err != nil {
+			invalid.Cause = err
+			return invalid
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return invalid
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return invalid
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return invalid
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		// byte slices
+		v.SetBytes([]byte(value))
+	default:
+		return &ParseError{Kind: KindUnsupportedFormat, Value: value, Reason: "unsupported type " + v.Type().String()}
 	}
-	return mapToStruct("path", out, data)
+	return nil
+}
+
+// prependPath adds the property or the index of a value to the path of a ParseError.
+func prependPath(err error, segment interface{}) error {
+	if pe, ok := err.(*ParseError); ok {
+		pe.path = append([]interface{}{segment}, pe.path...)
+	}
+	return err
 }
 
-func cookieParser(c *fiber.Ctx, out interface{}) error {
+// convertError converts an error of decode into a ValidationError naming the parameter.
+func (p *boundParameter) convertError(err error) *ValidationError {
+	field := p.name
+	if pe, ok := err.(*ParseError); ok {
+		for _, segment := range pe.path {
+			field += fmt.Sprintf(".%v", segment)
+		}
+		return NewValidationError(p.in, field, pe.Reason)
+	}
+	return NewValidationError(p.in, field, err.Error())
+}
+
+// formParser binds an application/x-www-form-urlencoded body into out.
+func formParser(c *fiber.Ctx, out interface{}) error {
 	data := make(map[string][]string)
-	c.Request().Header.VisitAllCookie(func(key, val []byte) {
+	c.Request().PostArgs().VisitAll(func(key, val []byte) {
 		k := utils.UnsafeString(key)
-		v := utils.UnsafeString(val)
-		if strings.Contains(v, ",") {
-			values := strings.Split(v, ",")
-			for i := 0; i < len(values); i++ {
-				data[k] = append(data[k], values[i])
+		data[k] = append(data[k], utils.UnsafeString(val))
+	})
+	return mapToStruct("form", out, data)
+}
+
+// multipartParser binds the values and the files of a multipart form into out,
+// files are bound to *multipart.FileHeader and []*multipart.FileHeader fields.
+func multipartParser(c *fiber.Ctx, out interface{}) error {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return err
+	}
+	if err := mapToStruct("form", out, form.Value); err != nil {
+		return err
+	}
+	if errs := bindFiles(reflect.ValueOf(out).Elem(), form.File); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+var (
+	fileHeaderPtrType   = reflect.PtrTo(fileHeaderType)
+	fileHeaderSliceType = reflect.SliceOf(fileHeaderPtrType)
+)
+
+func bindFiles(v reflect.Value, files map[string][]*multipart.FileHeader) ValidationErrors {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs ValidationErrors
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		field := newFieldResolver(&f)
+		if field.shouldEmbed() {
+			errs = append(errs, bindFiles(reflect.Indirect(v.Field(i)), files)...)
+			continue
+		}
+		if field.ignored || (f.Type != fileHeaderPtrType && f.Type != fileHeaderSliceType) {
+			continue
+		}
+		name := field.name("form")
+		headers := files[name]
+		if len(headers) == 0 {
+			continue
+		}
+		for _, fh := range headers {
+			if err := field.checkFile(fh); err != nil {
+				errs = append(errs, NewValidationError(PositionBody, name, err.Error()))
 			}
+		}
+		if f.Type == fileHeaderPtrType {
+			v.Field(i).Set(reflect.ValueOf(headers[0]))
 		} else {
-			data[k] = append(data[k], v)
+			v.Field(i).Set(reflect.ValueOf(headers))
 		}
-	})
-	return mapToStruct("cookie", out, data)
+	}
+	return errs
 }
 
 func mapToStruct(aliasTag string, out interface{}, data map[string][]string) error {
-	// Get decoder from pool
 	decoder := schema.NewDecoder()
 	decoder.SetAliasTag(aliasTag)
 	decoder.IgnoreUnknownKeys(true)
-	// Set alias tag
-	err := decoder.Decode(out, data)
-	return err
+	return decoder.Decode(out, data)
 }
@@ -0,0 +1,346 @@
+package soda
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type uploadBody struct {
+	Title  string                  `form:"title" validate:"required"`
+	Avatar *multipart.FileHeader   `form:"avatar" oai:"contentType=image/png,image/jpeg;maxLength=8"`
+	Files  []*multipart.FileHeader `form:"files"`
+}
+
+// testPart is a part of a multipart body, a file when filename is set.
+type testPart struct {
+	name, filename, contentType, content string
+}
+
+// multipartBody encodes parts as a multipart/form-data body and returns it with its Content-Type.
+func multipartBody(t *testing.T, parts ...testPart) (string, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		header := make(textproto.MIMEHeader)
+		disposition := fmt.Sprintf(`form-data; name=%q`, p.name)
+		if p.filename != "" {
+			disposition += fmt.Sprintf(`; filename=%q`, p.filename)
+		}
+		header.Set(fiber.HeaderContentDisposition, disposition)
+		if p.contentType != "" {
+			header.Set(fiber.HeaderContentType, p.contentType)
+		}
+		part, err := w.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write([]byte(p.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String(), w.FormDataContentType()
+}
+
+func TestMultipartRequestBody(t *testing.T) {
+	app := New("test", "1.0.0", EnableValidateRequest())
+	op := mustRegister(t, app.Post("/upload", func(c *fiber.Ctx) error {
+		body := c.Locals(KeyRequestBody).(*uploadBody)
+		names := []string{body.Title}
+		if body.Avatar != nil {
+			names = append(names, body.Avatar.Filename)
+		}
+		for _, f := range body.Files {
+			names = append(names, f.Filename)
+		}
+		return c.SendString(strings.Join(names, ","))
+	}).SetMultipartRequestBody(uploadBody{}))
+
+	tests := []struct {
+		name   string
+		parts  []testPart
+		status int
+		want   string
+	}{
+		{"files", []testPart{
+			{name: "title", content: "neo"},
+			{name: "avatar", filename: "neo.png", contentType: "image/png", content: "png"},
+			{name: "files", filename: "a.txt", contentType: "text/plain", content: "a"},
+			{name: "files", filename: "b.txt", contentType: "text/plain", content: "b"},
+		}, 200, "neo,neo.png,a.txt,b.txt"},
+		{"without files", []testPart{{name: "title", content: "neo"}}, 200, "neo"},
+		{"too large", []testPart{
+			{name: "title", content: "neo"},
+			{name: "avatar", filename: "neo.png", contentType: "image/png", content: "0123456789"},
+		}, 400, `[{"field":"avatar","in":"body","message":"file \"neo.png\" exceeds 8 bytes"}]`},
+		{"content type", []testPart{
+			{name: "title", content: "neo"},
+			{name: "avatar", filename: "neo.gif", contentType: "image/gif", content: "gif"},
+		}, 400, `[{"field":"avatar","in":"body","message":"file \"neo.gif\" has content type \"image/gif\", expected image/png,image/jpeg"}]`},
+		{"required", []testPart{
+			{name: "avatar", filename: "neo.png", contentType: "image/png", content: "png"},
+		}, 400, `[{"field":"title","in":"body","message":"does not satisfy \"required\""}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := multipartBody(t, tt.parts...)
+			resp := doRequest(t, app, "POST", "/upload", body, fiber.HeaderContentType, contentType)
+			if resp.status != tt.status || resp.body != tt.want {
+				t.Errorf("response = %d %s, want %d %s", resp.status, resp.body, tt.status, tt.want)
+			}
+		})
+	}
+
+	media := op.Operation.RequestBody.Value.Content.Get(fiber.MIMEMultipartForm)
+	if media == nil {
+		t.Fatal("the multipart request body is not documented")
+	}
+	want := `{"additionalProperties":false,"properties":{"avatar":{"format":"binary","maxLength":8,"type":"string"},` +
+		`"files":{"items":{"format":"binary","type":"string"},"type":"array"},"title":{"type":"string"}},` +
+		`"required":["title","files"],"type":"object"}`
+	if got := toJSON(t, media.Schema.Value); got != want {
+		t.Errorf("schema = %s, want %s", got, want)
+	}
+	if got := toJSON(t, media.Encoding); got != `{"avatar":{"contentType":"image/png,image/jpeg"}}` {
+		t.Errorf("encoding = %s", got)
+	}
+}
+
+func TestBindFiles(t *testing.T) {
+	png := &multipart.FileHeader{Filename: "a.png", Size: 3, Header: textproto.MIMEHeader{"Content-Type": {"image/png"}}}
+	txt := &multipart.FileHeader{Filename: "b.txt", Size: 1, Header: textproto.MIMEHeader{"Content-Type": {"text/plain"}}}
+	var body uploadBody
+	errs := bindFiles(reflect.ValueOf(&body).Elem(), map[string][]*multipart.FileHeader{
+		"avatar": {png, txt},
+		"files":  {txt, png},
+	})
+	if len(errs) != 1 || errs[0].Field != "avatar" {
+		t.Errorf("errors = %v", errs)
+	}
+	if body.Avatar != png || !reflect.DeepEqual(body.Files, []*multipart.FileHeader{txt, png}) {
+		t.Errorf("body = %+v", body)
+	}
+}
+
+type loginBody struct {
+	Username string   `form:"username" validate:"required"`
+	Password string   `form:"password" oai:"minLength=8"`
+	Remember bool     `form:"remember"`
+	Scopes   []string `form:"scope"`
+}
+
+func TestFormRequestBody(t *testing.T) {
+	app := New("test", "1.0.0", EnableValidateRequest())
+	op := mustRegister(t, app.Post("/login", func(c *fiber.Ctx) error {
+		return c.JSON(c.Locals(KeyRequestBody))
+	}).SetFormRequestBody(loginBody{}))
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		status      int
+		want        string
+	}{
+		{"form", "username=neo&password=red+pill+blue&remember=true&scope=a&scope=b", fiber.MIMEApplicationForm, 200,
+			`{"Username":"neo","Password":"red pill blue","Remember":true,"Scopes":["a","b"]}`},
+		{"conversion", "username=neo&remember=maybe", fiber.MIMEApplicationForm, 400,
+			`[{"field":"remember","in":"body","message":"invalid value, expected bool"}]`},
+		{"required", "password=xxxxxxxx", fiber.MIMEApplicationForm, 400,
+			`[{"field":"username","in":"body","message":"does not satisfy \"required\""}]`},
+		{"unsupported", `{"username":"neo"}`, fiber.MIMEApplicationJSON, 415, "Unsupported Media Type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, app, "POST", "/login", tt.body, fiber.HeaderContentType, tt.contentType)
+			if resp.status != tt.status || resp.body != tt.want {
+				t.Errorf("response = %d %s, want %d %s", resp.status, resp.body, tt.status, tt.want)
+			}
+		})
+	}
+
+	media := op.Operation.RequestBody.Value.Content.Get(fiber.MIMEApplicationForm)
+	if media == nil {
+		t.Fatal("the form request body is not documented")
+	}
+	want := `{"additionalProperties":false,"properties":{"password":{"minLength":8,"type":"string"},` +
+		`"remember":{"type":"boolean"},"scope":{"items":{"type":"string"},"type":"array"},"username":{"type":"string"}},` +
+		`"required":["username","password","remember","scope"],"type":"object"}`
+	if got := toJSON(t, media.Schema.Value); got != want {
+		t.Errorf("schema = %s, want %s", got, want)
+	}
+}
+
+type styleFilter struct {
+	Min  int    `query:"min"`
+	Name string `query:"name"`
+}
+
+type stylePoint struct {
+	X int `query:"x" path:"x" header:"x"`
+	Y int `query:"y" path:"y" header:"y"`
+}
+
+type styleQuery struct {
+	Form   []int             `query:"form"`
+	CSV    []int             `query:"csv" oai:"explode=false"`
+	Space  []string          `query:"space" oai:"style=spaceDelimited;explode=false"`
+	Pipe   []string          `query:"pipe" oai:"style=pipeDelimited;explode=false"`
+	Filter *styleFilter      `query:"filter" oai:"style=deepObject"`
+	Labels map[string]string `query:"labels" oai:"style=deepObject"`
+	Point  *stylePoint       `query:"point" oai:"explode=false"`
+	Text   string            `query:"text"`
+}
+
+type styleHeader struct {
+	IDs   []int       `header:"X-Ids"`
+	Text  string      `header:"X-Text"`
+	Point *stylePoint `header:"X-Point" oai:"explode=true"`
+	Token []string    `cookie:"token" oai:"explode=false"`
+}
+
+type simplePath struct {
+	IDs []int `path:"ids"`
+}
+
+type labelPath struct {
+	IDs []int `path:"ids" oai:"style=label;explode=false"`
+}
+
+type labelExplodedPath struct {
+	IDs []int `path:"ids" oai:"style=label;explode=true"`
+}
+
+type matrixPath struct {
+	IDs []int `path:"ids" oai:"style=matrix;explode=false"`
+}
+
+type matrixExplodedPath struct {
+	IDs []int `path:"ids" oai:"style=matrix;explode=true"`
+}
+
+type objectPath struct {
+	Point stylePoint `path:"point" oai:"explode=true"`
+}
+
+func TestParameterStyles(t *testing.T) {
+	app := New("test", "1.0.0")
+	for path, model := range map[string]interface{}{
+		"/query":                styleQuery{},
+		"/header":               styleHeader{},
+		"/simple/:ids":          simplePath{},
+		"/label/:ids":           labelPath{},
+		"/label-exploded/:ids":  labelExplodedPath{},
+		"/matrix/:ids":          matrixPath{},
+		"/matrix-exploded/:ids": matrixExplodedPath{},
+		"/point/:point":         objectPath{},
+	} {
+		mustRegister(t, app.Get(path, func(c *fiber.Ctx) error {
+			return c.JSON(c.Locals(KeyParameter))
+		}).SetParameters(model))
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		headers []string
+		status  int
+		want    string
+	}{
+		{"form exploded", "/query?form=1&form=2", nil, 200,
+			`{"Form":[1,2],"CSV":null,"Space":null,"Pipe":null,"Filter":null,"Labels":null,"Point":null,"Text":""}`},
+		{"form", "/query?csv=1,2", nil, 200,
+			`{"Form":null,"CSV":[1,2],"Space":null,"Pipe":null,"Filter":null,"Labels":null,"Point":null,"Text":""}`},
+		{"space and pipe delimited", "/query?space=a%20b&pipe=c|d", nil, 200,
+			`{"Form":null,"CSV":null,"Space":["a","b"],"Pipe":["c","d"],"Filter":null,"Labels":null,"Point":null,"Text":""}`},
+		{"deep object", "/query?filter[min]=3&filter[name]=neo&labels[a]=1&labels[b]=2", nil, 200,
+			`{"Form":null,"CSV":null,"Space":null,"Pipe":null,"Filter":{"Min":3,"Name":"neo"},"Labels":{"a":"1","b":"2"},"Point":null,"Text":""}`},
+		{"form object", "/query?point=x,1,y,2", nil, 200,
+			`{"Form":null,"CSV":null,"Space":null,"Pipe":null,"Filter":null,"Labels":null,"Point":{"X":1,"Y":2},"Text":""}`},
+		{"commas of strings", "/query?text=a,b", nil, 200,
+			`{"Form":null,"CSV":null,"Space":null,"Pipe":null,"Filter":null,"Labels":null,"Point":null,"Text":"a,b"}`},
+		{"simple header and cookie", "/header", []string{"X-Ids", "1,2", "X-Text", "a,b", "X-Point", "x=1,y=2", "Cookie", "token=a,b"}, 200,
+			`{"IDs":[1,2],"Text":"a,b","Point":{"X":1,"Y":2},"Token":["a","b"]}`},
+		{"simple path", "/simple/1,2,3", nil, 200, `{"IDs":[1,2,3]}`},
+		{"label", "/label/.1,2", nil, 200, `{"IDs":[1,2]}`},
+		{"label exploded", "/label-exploded/.1.2", nil, 200, `{"IDs":[1,2]}`},
+		{"matrix", "/matrix/;ids=1,2", nil, 200, `{"IDs":[1,2]}`},
+		{"matrix exploded", "/matrix-exploded/;ids=1;ids=2", nil, 200, `{"IDs":[1,2]}`},
+		{"simple object", "/point/x=1,y=2", nil, 200, `{"Point":{"X":1,"Y":2}}`},
+		{"label prefix", "/label/1,2", nil, 400, `[{"field":"ids","in":"path","message":"invalid value, expected the prefix \".\""}]`},
+		{"odd pairs", "/query?point=x,1,y", nil, 400, `[{"field":"point","in":"query","message":"invalid value, expected pairs of keys and values"}]`},
+		{"assignments", "/point/x=1,y", nil, 400, `[{"field":"point","in":"path","message":"invalid value, expected key=value"}]`},
+		{"item", "/query?form=1&form=a", nil, 400, `[{"field":"form.1","in":"query","message":"invalid value, expected int"}]`},
+		{"property", "/query?filter[min]=a", nil, 400, `[{"field":"filter.min","in":"query","message":"invalid value, expected int"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, app, "GET", tt.target, "", tt.headers...)
+			if resp.status != tt.status || resp.body != tt.want {
+				t.Errorf("response = %d %s, want %d %s", resp.status, resp.body, tt.status, tt.want)
+			}
+		})
+	}
+}
+
+type deepObjectArray struct {
+	IDs []int `query:"ids" oai:"style=deepObject"`
+}
+
+type pipeDelimitedString struct {
+	Name string `query:"name" oai:"style=pipeDelimited"`
+}
+
+type matrixQuery struct {
+	Name string `query:"name" oai:"style=matrix"`
+}
+
+func TestUnsupportedParameterStyles(t *testing.T) {
+	tests := []struct {
+		model interface{}
+		want  string
+	}{
+		{deepObjectArray{}, "deepObject"},
+		{pipeDelimitedString{}, "pipeDelimited"},
+		{matrixQuery{}, "matrix"},
+	}
+	for _, tt := range tests {
+		app := New("test", "1.0.0")
+		err := app.Get("/items", func(c *fiber.Ctx) error { return nil }).SetParameters(tt.model).Register()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%T: err = %v", tt.model, err)
+		}
+	}
+}
+
+func TestValueShape(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  int
+	}{
+		{"", shapePrimitive},
+		{new(int), shapePrimitive},
+		{[]byte{}, shapePrimitive},
+		{time.Time{}, shapePrimitive},
+		{[]string{}, shapeArray},
+		{[2]int{}, shapeArray},
+		{styleFilter{}, shapeObject},
+		{map[string]int{}, shapeObject},
+	}
+	for _, tt := range tests {
+		if got := valueShape(reflect.TypeOf(tt.value)); got != tt.want {
+			t.Errorf("valueShape(%T) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package soda
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// descriptions are the doc comments registered by RegisterDescriptions, keyed by the qualified names
+// of types, such as "github.com/acme/models.User", of fields, such as "github.com/acme/models.User.Name",
+// and of functions and methods as named by the runtime, such as "github.com/acme/api.(*Server).getUser".
+var descriptions = make(map[string]string)
+
+// RegisterDescriptions registers the doc comments of types, fields and handlers, which describe the schemas,
+// parameters and operations unless their description is given by the oai tag, SetSummary or SetDescription.
+// It is called by the files generated by the soda-doc command:
+//
+//	//go:generate go run github.com/captain-neo/soda/cmd/soda-doc
+func RegisterDescriptions(docs map[string]string) {
+	for name, doc := range docs {
+		descriptions[name] = doc
+	}
+}
+
+// typeDescription returns the doc comment of the named type t.
+func typeDescription(t reflect.Type) string {
+	if t.Name() == "" || t.PkgPath() == "" {
+		return ""
+	}
+	return descriptions[typeDocName(t)]
+}
+
+// fieldDescription returns the doc comment of the field of struct t at index, which may go through embedded structs.
+func fieldDescription(t reflect.Type, index []int) string {
+	for _, i := range index[:len(index)-1] {
+		t = indirectType(t.Field(i).Type)
+	}
+	if t.Name() == "" || t.PkgPath() == "" {
+		return ""
+	}
+	return descriptions[typeDocName(t)+"."+t.Field(index[len(index)-1]).Name]
+}
+
+// typeDocName names t as the soda-doc command does, without the type arguments of generic types.
+func typeDocName(t reflect.Type) string {
+	name := t.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	return t.PkgPath() + "." + name
+}
+
+// funcName returns the name of the function or the method value fn, as named by the soda-doc command.
+func funcName(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	f := runtime.FuncForPC(v.Pointer())
+	if f == nil {
+		return ""
+	}
+	// method values are suffixed with "-fm", instances of generic functions hold "[...]"
+	return strings.Replace(strings.TrimSuffix(f.Name(), "-fm"), "[...]", "", 1)
+}
+
+// describeHandler returns the summary and the description of an operation from the doc comment of its handler.
+// The summary is the first sentence of the comment without the name of the handler,
+// such as "Returns a user." for "getUser returns a user.", the description is the whole comment if it says more.
+func describeHandler(fn interface{}) (summary, description string) {
+	name := funcName(fn)
+	doc := strings.TrimSpace(descriptions[name])
+	if doc == "" {
+		return "", ""
+	}
+	first := doc
+	if i := strings.Index(first, "\n\n"); i >= 0 {
+		first = first[:i]
+	}
+	if i := strings.Index(first, ". "); i >= 0 {
+		first = first[:i+1]
+	}
+	if len(first) < len(doc) {
+		description = doc
+	}
+	short := name[strings.LastIndexByte(name, '.')+1:]
+	if i := strings.IndexByte(first, ' '); i >= 0 && first[:i] == short {
+		first = first[i+1:]
+	}
+	runes := []rune(first)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes), description
+}
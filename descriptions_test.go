@@ -0,0 +1,133 @@
+package soda
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type docBase struct {
+	Created string `json:"created"`
+}
+
+type docUser struct {
+	docBase
+	Name  string `json:"name"`
+	Email string `json:"email" oai:"description=the tag wins"`
+	Role  docRole
+}
+
+type docRole string
+
+type docPage[T any] struct {
+	Items []T `json:"items"`
+}
+
+type docParameters struct {
+	Limit int `query:"limit"`
+}
+
+type docServer struct{}
+
+func (*docServer) listUsers(c *fiber.Ctx) error { return nil }
+
+func getDocUser(c *fiber.Ctx) error { return nil }
+
+func init() {
+	RegisterDescriptions(map[string]string{
+		"github.com/captain-neo/soda.docBase.Created":        "Created is the creation date.",
+		"github.com/captain-neo/soda.docUser":                "docUser is a user.",
+		"github.com/captain-neo/soda.docUser.Name":           "Name is the full name.",
+		"github.com/captain-neo/soda.docUser.Email":          "Email is ignored.",
+		"github.com/captain-neo/soda.docRole":                "docRole is the role of a user.",
+		"github.com/captain-neo/soda.docPage":                "docPage is a page of items.",
+		"github.com/captain-neo/soda.docParameters.Limit":    "Limit is the size of a page.",
+		"github.com/captain-neo/soda.getDocUser":             "getDocUser returns a user. It fails if the user is unknown.",
+		"github.com/captain-neo/soda.(*docServer).listUsers": "listUsers lists the users.",
+	})
+}
+
+func TestDescribeHandler(t *testing.T) {
+	RegisterDescriptions(map[string]string{
+		"github.com/captain-neo/soda.describedOne":   "describedOne answers the request.",
+		"github.com/captain-neo/soda.describedTwo":   "Answers the request.\n\nIt is described.",
+		"github.com/captain-neo/soda.describedThree": "describedThree",
+	})
+	tests := []struct {
+		name                 string
+		handler              interface{}
+		summary, description string
+	}{
+		{"sentence", describedOne, "Answers the request.", ""},
+		{"paragraphs", describedTwo, "Answers the request.", "Answers the request.\n\nIt is described."},
+		{"name only", describedThree, "DescribedThree", ""},
+		{"several sentences", getDocUser, "Returns a user.", "getDocUser returns a user. It fails if the user is unknown."},
+		{"method value", (&docServer{}).listUsers, "Lists the users.", ""},
+		{"undocumented", func(c *fiber.Ctx) error { return nil }, "", ""},
+		{"nil", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, description := describeHandler(tt.handler)
+			if summary != tt.summary || description != tt.description {
+				t.Errorf("describeHandler = %q %q, want %q %q", summary, description, tt.summary, tt.description)
+			}
+		})
+	}
+}
+
+func describedOne()   {}
+func describedTwo()   {}
+func describedThree() {}
+
+func TestSchemaDescriptions(t *testing.T) {
+	schema := generateSchema(t, docUser{})
+	page := generateSchema(t, docPage[docUser]{})
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"type", schema.Description, "docUser is a user."},
+		{"field", schema.Properties["name"].Value.Description, "Name is the full name."},
+		{"promoted field", schema.Properties["created"].Value.Description, "Created is the creation date."},
+		{"oai tag", schema.Properties["email"].Value.Description, "the tag wins"},
+		{"type of the field", schema.Properties["Role"].Value.Description, "docRole is the role of a user."},
+		{"generic type", page.Description, "docPage is a page of items."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("description = %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOperationDescriptions(t *testing.T) {
+	app := New("test", "1.0.0")
+	tests := []struct {
+		name                 string
+		op                   *Operation
+		summary, description string
+	}{
+		{"handler", app.Get("/user", getDocUser),
+			"Returns a user.", "getDocUser returns a user. It fails if the user is unknown."},
+		{"method value", app.Get("/users", (&docServer{}).listUsers), "Lists the users.", ""},
+		{"set summary", app.Get("/admin", getDocUser).SetSummary("Returns an admin.").SetDescription("An admin."),
+			"Returns an admin.", "An admin."},
+		{"parameters", app.Get("/roles", func(c *fiber.Ctx) error { return nil }).SetParameters(docParameters{}), "GET /roles", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := mustRegister(t, tt.op)
+			if op.Operation.Summary != tt.summary || op.Operation.Description != tt.description {
+				t.Errorf("operation = %q %q, want %q %q", op.Operation.Summary, op.Operation.Description, tt.summary, tt.description)
+			}
+		})
+	}
+	limit := app.OpenAPI().Paths["/roles"].Get.Parameters.GetByInAndName("query", "limit")
+	if limit == nil || limit.Description != "Limit is the size of a page." {
+		t.Errorf("parameter = %+v", limit)
+	}
+}
@@ -13,9 +13,30 @@ type OpenAPISpecError struct {
 }
 
 func (oe OpenAPISpecError) Error() string {
+	if oe.Field == "" {
+		return fmt.Sprintf("openapi spec error: %s is invalid, cause of %s", oe.Position, oe.Reason)
+	}
 	return fmt.Sprintf("openapi spec error: field %s in %s is invalid, cause of %s", oe.Field, oe.Position, oe.Reason)
 }
 
+// OpenAPISpecErrors aggregates all the problems found while building the OpenAPI specification.
+type OpenAPISpecErrors []OpenAPISpecError
+
+func (oes OpenAPISpecErrors) Error() string {
+	msg := make([]string, 0, len(oes))
+	for _, oe := range oes {
+		msg = append(msg, oe.Error())
+	}
+	return strings.Join(msg, "\n")
+}
+
+func (oes OpenAPISpecErrors) orNil() error {
+	if len(oes) == 0 {
+		return nil
+	}
+	return oes
+}
+
 type ValidationError struct {
 	Field    string `json:"field"`
 	Position string `json:"in"`
@@ -30,6 +51,17 @@ func (ve ValidationError) Error() string {
 	return fmt.Sprintf("validation error: field %q in %s is invalid, cause of %s", ve.Field, ve.Position, ve.Reason)
 }
 
+// ValidationErrors aggregates all the problems found while validating a request.
+type ValidationErrors []*ValidationError
+
+func (ves ValidationErrors) Error() string {
+	msg := make([]string, 0, len(ves))
+	for _, ve := range ves {
+		msg = append(msg, ve.Error())
+	}
+	return strings.Join(msg, "\n")
+}
+
 // ParseErrorKind describes a kind of ParseError.
 // The type simplifies comparison of errors.
 type ParseErrorKind int
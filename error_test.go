@@ -0,0 +1,101 @@
+package soda
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type badMinimum struct {
+	Age int `json:"age" oai:"minimum=abc"`
+}
+
+type badLocation struct {
+	Name string `json:"name"`
+}
+
+func TestRegisterReturnsSpecErrors(t *testing.T) {
+	handler := func(c *fiber.Ctx) error { return nil }
+	tests := []struct {
+		name     string
+		register func(app *Soda) error
+		want     OpenAPISpecError
+	}{
+		{
+			name: "invalid oai tag",
+			register: func(app *Soda) error {
+				return app.Post("/users", handler).SetJSONRequestBody(badMinimum{}).Register()
+			},
+			want: OpenAPISpecError{Position: "POST /users", Field: "badMinimum.Age", Reason: `oai:"minimum=abc"`},
+		},
+		{
+			name: "parameter without location",
+			register: func(app *Soda) error {
+				return app.Get("/users", handler).SetParameters(badLocation{}).Register()
+			},
+			want: OpenAPISpecError{Position: "GET /users", Field: "badLocation.Name", Reason: "parameter location is unknown"},
+		},
+		{
+			name: "undeclared path parameter",
+			register: func(app *Soda) error {
+				return app.Get("/users/:id", handler).Register()
+			},
+			want: OpenAPISpecError{Position: "GET /users/{id}", Reason: "missing: [id]"},
+		},
+		{
+			name: "duplicate operation id",
+			register: func(app *Soda) error {
+				mustRegister(t, app.Get("/a", handler).SetOperationID("same"))
+				return app.Get("/b", handler).SetOperationID("same").Register()
+			},
+			want: OpenAPISpecError{Position: "GET /b", Reason: `operation id "same" is used by GET /a as well`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New("test", "1.0.0")
+			err := tt.register(app)
+			var errs OpenAPISpecErrors
+			if !errors.As(err, &errs) || len(errs) != 1 {
+				t.Fatalf("Register() = %v, want one OpenAPISpecError", err)
+			}
+			got := errs[0]
+			if got.Position != tt.want.Position || got.Field != tt.want.Field || !strings.Contains(got.Reason, tt.want.Reason) {
+				t.Errorf("Register() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildAggregatesErrors(t *testing.T) {
+	app := New("test", "1.0.0")
+	handler := func(c *fiber.Ctx) error { return nil }
+	_ = app.Post("/a", handler).SetJSONRequestBody(badMinimum{}).Register()
+	_ = app.Get("/b", handler).SetParameters(badLocation{}).Register()
+	mustRegister(t, app.Get("/c", handler))
+
+	var errs OpenAPISpecErrors
+	if err := app.Build(); !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("Build() = %v, want the errors of both operations", err)
+	}
+	if errs[0].Position != "POST /a" || errs[1].Position != "GET /b" {
+		t.Errorf("Build() = %v", errs)
+	}
+	paths := app.OpenAPI().Paths
+	if paths.Find("/a") != nil || paths.Find("/b") != nil || paths.Find("/c") == nil {
+		t.Error("only the valid operations belong to the specification")
+	}
+}
+
+func TestBuildWithoutErrors(t *testing.T) {
+	app := New("test", "1.0.0")
+	mustRegister(t, app.Get("/", func(c *fiber.Ctx) error { return nil }))
+	if err := app.Build(); err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	if len(app.GetOpenAPIJSON()) == 0 {
+		t.Error("the specification is not rendered")
+	}
+}
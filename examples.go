@@ -0,0 +1,129 @@
+package soda
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// namedExample is an example of a request body or of a response, added to the specification when the operation is registered.
+type namedExample struct {
+	name    string
+	summary string
+	value   interface{}
+}
+
+// AddRequestExample documents value as the example name of the request body, shown by the documentation renderers.
+// The example is encoded in JSON and checked against the schema of the request body when the operation is registered.
+func (op *Operation) AddRequestExample(name, summary string, value interface{}) *Operation {
+	op.requestExamples = append(op.requestExamples, namedExample{name: name, summary: summary, value: value})
+	return op
+}
+
+// AddResponseExample documents value as the example name of the response with status, or of the default response
+// if status is 0. It is encoded in JSON and checked against the schema of the response when the operation is registered,
+// the validation error and problem responses documented by soda take examples as well.
+func (op *Operation) AddResponseExample(status int, name string, value interface{}) *Operation {
+	if op.responseExamples == nil {
+		op.responseExamples = make(map[int][]namedExample)
+	}
+	op.responseExamples[status] = append(op.responseExamples[status], namedExample{name: name, value: value})
+	return op
+}
+
+// addExamples adds the examples to the JSON media types of the request body and of the responses.
+func (op *Operation) addExamples() {
+	if len(op.requestExamples) > 0 {
+		if op.Operation.RequestBody == nil || op.Operation.RequestBody.Value == nil {
+			op.addError("", "request examples are given but the request body is not documented")
+		} else {
+			op.addContentExamples("the request body", op.Operation.RequestBody.Value.Content, op.requestExamples)
+		}
+	}
+	statuses := make([]int, 0, len(op.responseExamples))
+	for status := range op.responseExamples {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		key := responseKey(status)
+		response := op.Operation.Responses[key]
+		if response == nil || response.Value == nil {
+			op.addError("", fmt.Sprintf("examples are given but the response %s is not documented", key))
+			continue
+		}
+		// the examples belong to the operation, not to the shared response component
+		if response.Ref != "" {
+			response = inlineResponse(response)
+			op.Operation.Responses[key] = response
+		}
+		op.addContentExamples("the response "+key, response.Value.Content, op.responseExamples[status])
+	}
+}
+
+// inlineResponse copies the response referenced by ref and its media types, so that examples can be added to them.
+func inlineResponse(ref *openapi3.ResponseRef) *openapi3.ResponseRef {
+	response := *ref.Value
+	response.Content = make(openapi3.Content, len(ref.Value.Content))
+	for ct, mediaType := range ref.Value.Content {
+		copied := *mediaType
+		response.Content[ct] = &copied
+	}
+	return &openapi3.ResponseRef{Value: &response}
+}
+
+func (op *Operation) addContentExamples(subject string, content openapi3.Content, examples []namedExample) {
+	var mediaTypes []*openapi3.MediaType
+	for ct, mediaType := range content {
+		// examples are encoded by encoding/json, so that they match the schemas named after the json tag,
+		// such as the ones of application/problem+json
+		if codec, ok := op.Soda.Options.codecs[ct]; ok && codec.NameTag() == "json" || strings.HasSuffix(ct, "+json") {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	if len(mediaTypes) == 0 {
+		op.addError("", fmt.Sprintf("examples of %s are only documented for JSON content types", subject))
+		return
+	}
+	for _, e := range examples {
+		value, err := jsonValue(e.value)
+		if err != nil {
+			op.addError("", fmt.Sprintf("example %q of %s can not be encoded: %v", e.name, subject, err))
+			continue
+		}
+		for _, mediaType := range mediaTypes {
+			if mediaType.Schema != nil && mediaType.Schema.Value != nil {
+				if err := mediaType.Schema.Value.VisitJSON(value); err != nil {
+					for _, ve := range convertSchemaError("", "", err) {
+						reason := ve.Reason
+						if ve.Field != "" {
+							reason = fmt.Sprintf("%q %s", ve.Field, reason)
+						}
+						op.addError("", fmt.Sprintf("example %q of %s does not match its schema: %s", e.name, subject, reason))
+					}
+					break
+				}
+			}
+			if mediaType.Examples == nil {
+				mediaType.Examples = make(openapi3.Examples)
+			}
+			example := openapi3.NewExample(value)
+			example.Summary = e.summary
+			mediaType.Examples[e.name] = &openapi3.ExampleRef{Value: example}
+		}
+	}
+}
+
+// jsonValue returns v as decoded from its JSON encoding, such as map[string]interface{} for structs.
+func jsonValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
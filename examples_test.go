@@ -0,0 +1,91 @@
+package soda
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type exampleUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name" oai:"minLength=2"`
+}
+
+func TestExamples(t *testing.T) {
+	handler := func(c *fiber.Ctx) error { return nil }
+	invalid := ValidationErrors{NewValidationError("query", "limit", "is required")}
+	tests := []struct {
+		name    string
+		options []Option
+		op      func(app *Soda) *Operation
+		status  string
+		want    string
+		wantErr string
+	}{
+		{"request body", nil, func(app *Soda) *Operation {
+			return app.Post("/users", handler).SetJSONRequestBody(exampleUser{}).
+				AddRequestExample("neo", "a user", exampleUser{ID: 1, Name: "neo"})
+		}, "", `{"neo":{"summary":"a user","value":{"id":1,"name":"neo"}}}`, ""},
+		{"response", nil, func(app *Soda) *Operation {
+			return app.Get("/users", handler).AddJSONResponse(200, exampleUser{}).
+				AddResponseExample(200, "neo", exampleUser{ID: 1, Name: "neo"})
+		}, "200", `{"neo":{"value":{"id":1,"name":"neo"}}}`, ""},
+		{"validation error response", nil, func(app *Soda) *Operation {
+			return app.Get("/users", handler).SetParameters(listQuery{}).AddResponseExample(400, "missing limit", invalid)
+		}, "400", `{"missing limit":{"value":[{"field":"limit","in":"query","message":"is required"}]}}`, ""},
+		{"problem response", []Option{EnableProblemDetails()}, func(app *Soda) *Operation {
+			return app.Get("/users", handler).AddResponseExample(0, "not found", NewProblem(404, "no such user"))
+		}, "default", `{"not found":{"value":{"detail":"no such user","status":404,"title":"Not Found"}}}`, ""},
+		{"mismatch", nil, func(app *Soda) *Operation {
+			return app.Post("/users", handler).SetJSONRequestBody(exampleUser{}).AddRequestExample("short", "", exampleUser{Name: "n"})
+		}, "", "", `example "short" of the request body does not match its schema: "name"`},
+		{"undocumented response", nil, func(app *Soda) *Operation {
+			return app.Get("/users", handler).AddResponseExample(404, "missing", exampleUser{})
+		}, "", "", "examples are given but the response 404 is not documented"},
+		{"undocumented request body", nil, func(app *Soda) *Operation {
+			return app.Get("/users", handler).AddRequestExample("neo", "", exampleUser{})
+		}, "", "", "request examples are given but the request body is not documented"},
+		{"not JSON", nil, func(app *Soda) *Operation {
+			return app.Get("/users", handler).AddResponse(200, exampleUser{}, fiber.MIMEApplicationXML).
+				AddResponseExample(200, "neo", exampleUser{ID: 1, Name: "neo"})
+		}, "", "", "examples of the response 200 are only documented for JSON content types"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New("test", "1.0.0", tt.options...)
+			op := tt.op(app)
+			err := op.Register()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var content interface{}
+			if tt.status == "" {
+				content = op.Operation.RequestBody.Value.Content
+			} else {
+				response := op.Operation.Responses[tt.status]
+				if response.Ref != "" {
+					t.Errorf("the response %s refers to %s", tt.status, response.Ref)
+				}
+				content = response.Value.Content
+			}
+			if got := toJSON(t, content); !strings.Contains(got, `"examples":`+tt.want) {
+				t.Errorf("content = %s, want examples %s", got, tt.want)
+			}
+			// the shared components are left as they are
+			if got := toJSON(t, app.OpenAPI().Components.Responses); strings.Contains(got, "examples") {
+				t.Errorf("components = %s", got)
+			}
+		})
+	}
+}
+
+type listQuery struct {
+	Limit int `query:"limit"`
+}
@@ -1,23 +1,36 @@
 package soda
 
 import (
+	"fmt"
+	"mime"
+	"mime/multipart"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
 type fieldResolver struct {
-	f        *reflect.StructField
-	tagPairs map[string]string
-	ignored  bool
+	f             *reflect.StructField
+	tagPairs      map[string]string
+	validateRules []validateRule
+	ignored       bool
+}
+
+// validateRule is a single go-playground validator rule such as "min=1".
+type validateRule struct {
+	name  string
+	param string
 }
 
 func newFieldResolver(f *reflect.StructField) *fieldResolver {
 	resolver := &fieldResolver{
-		f:        f,
-		ignored:  false,
-		tagPairs: nil,
+		f:             f,
+		ignored:       false,
+		tagPairs:      nil,
+		validateRules: parseValidateRules(f.Tag.Get(ValidateTag)),
 	}
 	if oaiTags, oaiOK := f.Tag.Lookup(OpenAPITag); oaiOK {
 		tags := strings.Split(oaiTags, SeparatorProp)
@@ -39,7 +52,79 @@ func newFieldResolver(f *reflect.StructField) *fieldResolver {
 	return resolver
 }
 
+// check reports the first oai tag whose value can not be parsed.
+func (s fieldResolver) check() error {
+	for tag, val := range s.tagPairs {
+		var err error
+		switch tag {
+		case PropMinimum, PropMaximum, PropMultipleOf:
+			_, err = toFloatE(val)
+		case PropMinLength, PropMaxLength, PropMinItems, PropMaxItems:
+			_, err = strconv.ParseUint(val, 10, 64)
+		case PropRequired, PropDeprecated, PropAllowEmptyValue, PropNullable, PropReadOnly, PropWriteOnly,
+			PropExclusiveMinimum, PropExclusiveMaximum, PropUniqueItems, PropExplode:
+			if val != "" {
+				_, err = strconv.ParseBool(val)
+			}
+		case PropDefault:
+			if valueShape(s.f.Type) != shapeObject {
+				err = setValues(reflect.New(s.f.Type).Elem(), defaultValues(s.f.Type, val))
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%s:\"%s=%s\": %w", OpenAPITag, tag, val, err)
+		}
+	}
+	return nil
+}
+
+// checkFile checks the size of an uploaded file against the maxLength prop
+// and its content type against the contentType prop.
+func (s fieldResolver) checkFile(fh *multipart.FileHeader) error {
+	if v, ok := s.tagPairs[PropMaxLength]; ok && uint64(fh.Size) > toUint(v) {
+		return fmt.Errorf("file %q exceeds %s bytes", fh.Filename, v)
+	}
+	v, ok := s.tagPairs[PropContentType]
+	if !ok {
+		return nil
+	}
+	contentType := fh.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	for _, allowed := range strings.Split(v, SeparatorPropItem) {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == contentType || allowed == "*/*" ||
+			(strings.HasSuffix(allowed, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(allowed, "*"))) {
+			return nil
+		}
+	}
+	return fmt.Errorf("file %q has content type %q, expected %s", fh.Filename, contentType, v)
+}
+
+func parseValidateRules(tag string) []validateRule {
+	if tag == "" || tag == "-" {
+		return nil
+	}
+	items := strings.Split(tag, ",")
+	rules := make([]validateRule, 0, len(items))
+	for _, item := range items {
+		// alternatives can not be described by a single schema
+		if strings.Contains(item, "|") {
+			continue
+		}
+		rule := validateRule{name: item}
+		if i := strings.Index(item, "="); i >= 0 {
+			rule.name, rule.param = item[:i], item[i+1:]
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// injectOAITags applies the validate tag first so that explicit oai tags win on conflict.
 func (s *fieldResolver) injectOAITags(schema *openapi3.Schema) {
+	injectValidateRules(schema, s.validateRules)
 	s.injectOAIGeneric(schema)
 	switch schema.Type {
 	case TypeString:
@@ -53,8 +138,24 @@ func (s *fieldResolver) injectOAITags(schema *openapi3.Schema) {
 	}
 }
 
-func (s fieldResolver) required() bool {
+// required tells if the field is required, pointers and fields with the omitempty option of nameTag are optional
+// unless the validate or the oai tag require them.
+func (s fieldResolver) required(nameTag ...string) bool {
 	required := s.f.Type.Kind() != reflect.Ptr
+	if len(nameTag) > 0 && (s.tagOption(nameTag[0], "omitempty") || s.tagOption(nameTag[0], "omitzero")) {
+		required = false
+	}
+	for _, rule := range s.validateRules {
+		if rule.name == "dive" {
+			break
+		}
+		switch rule.name {
+		case "required":
+			required = true
+		case "omitempty":
+			required = false
+		}
+	}
 
 	if v, ok := s.tagPairs[PropRequired]; ok {
 		required = toBool(v)
@@ -71,6 +172,17 @@ func (s fieldResolver) name(tag ...string) string {
 	return s.f.Name
 }
 
+// tagOption tells if the tag holds option after the name, such as "omitempty" in `json:"name,omitempty"`.
+func (s fieldResolver) tagOption(tag, option string) bool {
+	options := strings.Split(s.f.Tag.Get(tag), ",")
+	for _, o := range options[1:] {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
 func (s fieldResolver) shouldEmbed() bool {
 	return s.f.Anonymous && !s.ignored
 }
@@ -114,6 +226,7 @@ func (s *fieldResolver) injectOAIString(schema *openapi3.Schema) {
 				schema.Format = val
 			}
 		case PropEnum:
+			schema.Enum = nil
 			for _, item := range strings.Split(val, SeparatorPropItem) {
 				schema.Enum = append(schema.Enum, item)
 			}
@@ -157,6 +270,7 @@ func (s *fieldResolver) injectOAINumeric(schema *openapi3.Schema) { //nolint
 			}
 		case PropEnum:
 			items := strings.Split(val, SeparatorPropItem)
+			schema.Enum = nil
 			switch schema.Type {
 			case TypeInteger:
 				for _, item := range items {
@@ -213,3 +327,224 @@ func (s *fieldResolver) injectOAIBoolean(schema *openapi3.Schema) {
 		schema.Example = toBool(val)
 	}
 }
+
+// injectValidateRules translates go-playground validator rules into schema keywords,
+// rules following "dive" describe the items of arrays and the values of maps.
+func injectValidateRules(schema *openapi3.Schema, rules []validateRule) { //nolint
+	for i, rule := range rules {
+		switch rule.name {
+		case "dive":
+			var elem *openapi3.SchemaRef
+			switch schema.Type {
+			case TypeArray:
+				elem = schema.Items
+			case TypeObject:
+				elem = schema.AdditionalProperties
+			}
+			// referenced schemas are shared and must not be modified
+			if elem != nil && elem.Ref == "" && elem.Value != nil {
+				injectValidateRules(elem.Value, rules[i+1:])
+			}
+			return
+		case "min", "gte":
+			setLowerBound(schema, rule.param, false)
+		case "gt":
+			setLowerBound(schema, rule.param, true)
+		case "max", "lte":
+			setUpperBound(schema, rule.param, false)
+		case "lt":
+			setUpperBound(schema, rule.param, true)
+		case "len":
+			setLowerBound(schema, rule.param, false)
+			setUpperBound(schema, rule.param, false)
+		case "oneof":
+			schema.Enum = nil
+			for _, item := range strings.Fields(rule.param) {
+				switch schema.Type {
+				case TypeInteger:
+					if v, err := toIntE(item); err == nil {
+						schema.Enum = append(schema.Enum, v)
+					}
+				case TypeNumber:
+					if v, err := toFloatE(item); err == nil {
+						schema.Enum = append(schema.Enum, v)
+					}
+				default:
+					schema.Enum = append(schema.Enum, item)
+				}
+			}
+		case "email":
+			schema.Format = "email"
+		case "url", "uri", "http_url":
+			schema.Format = "uri"
+		case "uuid", "uuid3", "uuid4", "uuid5", "uuid_rfc4122", "uuid3_rfc4122", "uuid4_rfc4122", "uuid5_rfc4122":
+			schema.Format = "uuid"
+		case "hostname", "hostname_rfc1123", "fqdn":
+			schema.Format = "hostname"
+		case "ipv4", "ip4_addr":
+			schema.Format = "ipv4"
+		case "ipv6", "ip6_addr":
+			schema.Format = "ipv6"
+		case "ip", "ip_addr":
+			schema.AnyOf = ipSchemas()
+		case "alpha":
+			schema.Pattern = "^[a-zA-Z]+$"
+		case "alphanum":
+			schema.Pattern = "^[a-zA-Z0-9]+$"
+		case "numeric":
+			schema.Pattern = `^[-+]?[0-9]+(?:\.[0-9]+)?$`
+		}
+	}
+}
+
+// setLowerBound sets the minimum value, length, item count or property count of schema depending on its type.
+func setLowerBound(schema *openapi3.Schema, param string, exclusive bool) {
+	switch schema.Type {
+	case TypeInteger, TypeNumber:
+		if v, err := toFloatE(param); err == nil {
+			schema.Min = openapi3.Float64Ptr(v)
+			schema.ExclusiveMin = exclusive
+		}
+	case TypeString, TypeArray, TypeObject:
+		v, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return
+		}
+		if exclusive {
+			v++
+		}
+		switch schema.Type {
+		case TypeString:
+			schema.MinLength = v
+		case TypeArray:
+			schema.MinItems = v
+		case TypeObject:
+			schema.MinProps = v
+		}
+	}
+}
+
+// setUpperBound sets the maximum value, length, item count or property count of schema depending on its type.
+func setUpperBound(schema *openapi3.Schema, param string, exclusive bool) {
+	switch schema.Type {
+	case TypeInteger, TypeNumber:
+		if v, err := toFloatE(param); err == nil {
+			schema.Max = openapi3.Float64Ptr(v)
+			schema.ExclusiveMax = exclusive
+		}
+	case TypeString, TypeArray, TypeObject:
+		v, err := strconv.ParseUint(param, 10, 64)
+		if err != nil || (exclusive && v == 0) {
+			return
+		}
+		if exclusive {
+			v--
+		}
+		switch schema.Type {
+		case TypeString:
+			schema.MaxLength = openapi3.Uint64Ptr(v)
+		case TypeArray:
+			schema.MaxItems = openapi3.Uint64Ptr(v)
+		case TypeObject:
+			schema.MaxProps = openapi3.Uint64Ptr(v)
+		}
+	}
+}
+
+// structField is a property of a struct, which may be promoted from embedded structs.
+type structField struct {
+	// Index goes through the embedded structs.
+	reflect.StructField
+	name   string
+	tagged bool
+}
+
+// structFields returns the properties of struct t named after nameTag, following the rules of encoding/json:
+// unexported fields and fields named "-" are skipped, the fields of embedded structs without a name are promoted,
+// and of the fields with the same name the shallowest one wins, then the only one named by the tag, otherwise none.
+func structFields(t reflect.Type, nameTag string) []structField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var fields []structField
+	next := []embedded{{typ: t}}
+	var count, nextCount map[reflect.Type]int
+	visited := map[reflect.Type]bool{}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		count, nextCount = nextCount, map[reflect.Type]int{}
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+			for i := 0; i < e.typ.NumField(); i++ {
+				f := e.typ.Field(i)
+				ft := f.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				// the exported fields of unexported embedded structs are promoted
+				if !f.IsExported() && (!f.Anonymous || ft.Kind() != reflect.Struct) {
+					continue
+				}
+				tag := f.Tag.Get(nameTag)
+				if tag == "-" || newFieldResolver(&f).ignored {
+					continue
+				}
+				f.Index = append(append([]int(nil), e.index...), i)
+				name := strings.Split(tag, ",")[0]
+				if name != "" || !f.Anonymous || ft.Kind() != reflect.Struct {
+					field := structField{StructField: f, name: name, tagged: name != ""}
+					if name == "" {
+						field.name = f.Name
+					}
+					fields = append(fields, field)
+					// a struct embedded twice at the same depth gives conflicting fields
+					if count[e.typ] > 1 {
+						fields = append(fields, field)
+					}
+					continue
+				}
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, embedded{typ: ft, index: f.Index})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		if len(fields[i].Index) != len(fields[j].Index) {
+			return len(fields[i].Index) < len(fields[j].Index)
+		}
+		return fields[i].tagged && !fields[j].tagged
+	})
+	visible := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		dominant := fields[i]
+		if j-i == 1 || len(fields[i+1].Index) > len(dominant.Index) || (dominant.tagged && !fields[i+1].tagged) {
+			visible = append(visible, dominant)
+		}
+		i = j
+	}
+	sort.Slice(visible, func(i, j int) bool {
+		a, b := visible[i].Index, visible[j].Index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return visible
+}
@@ -0,0 +1,175 @@
+package soda
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type validateModel struct {
+	Name     string            `json:"name" validate:"required,min=1,max=20"`
+	Code     string            `json:"code" validate:"len=4"`
+	Age      int               `json:"age" validate:"gte=18,lt=100"`
+	Score    float64           `json:"score" validate:"gt=0,lte=1"`
+	Kind     string            `json:"kind" validate:"oneof=a b"`
+	Level    int               `json:"level" validate:"oneof=1 2 3"`
+	Email    string            `json:"email" validate:"email"`
+	Site     string            `json:"site" validate:"url"`
+	ID       string            `json:"id" validate:"uuid4"`
+	Addr     string            `json:"addr" validate:"ip"`
+	Tags     []string          `json:"tags" validate:"min=1,dive,max=10"`
+	Labels   map[string]string `json:"labels" validate:"max=5,dive,alpha"`
+	Nickname *string           `json:"nickname" validate:"required"`
+	Note     string            `json:"note" validate:"omitempty,max=100"`
+	Either   string            `json:"either" validate:"email|url"`
+	Limit    int               `json:"limit" validate:"min=1,max=50" oai:"maximum=10"`
+	Color    string            `json:"color" validate:"oneof=red blue" oai:"enum=green"`
+}
+
+func TestValidateTagConstraints(t *testing.T) {
+	schema := generateSchema(t, validateModel{})
+	tests := []struct {
+		property string
+		want     string
+	}{
+		{"name", `{"maxLength":20,"minLength":1,"type":"string"}`},
+		{"code", `{"maxLength":4,"minLength":4,"type":"string"}`},
+		{"age", `{"exclusiveMaximum":true,"maximum":100,"minimum":18,"type":"integer"}`},
+		{"score", `{"exclusiveMinimum":true,"format":"double","maximum":1,"minimum":0,"type":"number"}`},
+		{"kind", `{"enum":["a","b"],"type":"string"}`},
+		{"level", `{"enum":[1,2,3],"type":"integer"}`},
+		{"email", `{"format":"email","type":"string"}`},
+		{"site", `{"format":"uri","type":"string"}`},
+		{"id", `{"format":"uuid","type":"string"}`},
+		{"addr", `{"anyOf":[{"format":"ipv4","type":"string"},{"format":"ipv6","type":"string"}],"type":"string"}`},
+		{"tags", `{"items":{"maxLength":10,"type":"string"},"minItems":1,"type":"array"}`},
+		{"labels", `{"additionalProperties":{"pattern":"^[a-zA-Z]+$","type":"string"},"maxProperties":5,"type":"object"}`},
+		{"note", `{"maxLength":100,"type":"string"}`},
+		{"either", `{"type":"string"}`},
+		// explicit oai tags win on conflict
+		{"limit", `{"maximum":10,"minimum":1,"type":"integer"}`},
+		{"color", `{"enum":["green"],"type":"string"}`},
+	}
+	for _, tt := range tests {
+		prop := schema.Properties[tt.property]
+		if prop == nil {
+			t.Errorf("property %s is missing", tt.property)
+			continue
+		}
+		if got := toJSON(t, prop.Value); got != tt.want {
+			t.Errorf("property %s = %s, want %s", tt.property, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTagRequired(t *testing.T) {
+	schema := generateSchema(t, validateModel{})
+	want := []string{"name", "code", "age", "score", "kind", "level", "email", "site", "id", "addr", "tags", "labels", "nickname", "either", "limit", "color"}
+	if !reflect.DeepEqual(schema.Required, want) {
+		t.Errorf("required = %v, want %v", schema.Required, want)
+	}
+}
+
+func TestParseValidateRules(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want []validateRule
+	}{
+		{"", nil},
+		{"-", nil},
+		{"required", []validateRule{{name: "required"}}},
+		{"min=1,oneof=a b", []validateRule{{name: "min", param: "1"}, {name: "oneof", param: "a b"}}},
+		{"email|url,max=3", []validateRule{{name: "max", param: "3"}}},
+	}
+	for _, tt := range tests {
+		got := parseValidateRules(tt.tag)
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseValidateRules(%q) = %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+type jsonBase struct {
+	ID      int    `json:"id"`
+	Created string `json:"created"`
+}
+
+type jsonAudit struct {
+	Created string `json:"created"`
+	Author  string
+}
+
+type jsonNote struct {
+	Author string
+}
+
+type jsonHidden struct {
+	Secret string `json:"secret"`
+}
+
+type jsonModel struct {
+	jsonBase
+	*jsonAudit
+	jsonNote
+	jsonHidden `json:"-"`
+	Owner      jsonBase `json:"owner"`
+	Name       string   `json:"name"`
+	Title      string
+	Count      int    `json:",omitempty"`
+	Dash       string `json:"-,"`
+	Skipped    string `json:"-"`
+	private    string //nolint:unused
+}
+
+type jsonConflict struct {
+	jsonAudit
+	jsonNote
+}
+
+type jsonTagged struct {
+	jsonNamed
+	jsonNote
+}
+
+type jsonNamed struct {
+	Author string `json:"Author"`
+}
+
+func TestStructFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		model interface{}
+		want  []string
+	}{
+		// created of jsonBase and jsonAudit conflict, as do Author of jsonAudit and jsonNote
+		{"embedded", jsonModel{jsonAudit: &jsonAudit{}, Count: 1}, []string{"id", "owner", "name", "Title", "Count", "-"}},
+		{"conflict", jsonConflict{}, []string{"created"}},
+		{"tagged wins", jsonTagged{}, []string{"Author"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, f := range structFields(reflect.TypeOf(tt.model), "json") {
+				got = append(got, f.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+			// encoding/json encodes the same properties
+			var props map[string]interface{}
+			if err := json.Unmarshal([]byte(toJSON(t, tt.model)), &props); err != nil {
+				t.Fatal(err)
+			}
+			encoded := sortedKeys(props)
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(encoded, want) {
+				t.Errorf("encoding/json encodes %v", encoded)
+			}
+		})
+	}
+}
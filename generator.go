@@ -8,17 +8,31 @@ import (
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
 )
 
 type oaiGenerator struct {
-	openapi *openapi3.T
+	openapi     *openapi3.T
+	errs        OpenAPISpecErrors
+	schemaNamer SchemaNamer
+	// schemaTypes are the types of the schema components.
+	schemaTypes map[string]reflect.Type
+	// componentOwners are the models of the request body and response components.
+	componentOwners map[string]reflect.Type
+	// building are the schemas of the structs being generated, which recursive fields refer to.
+	building map[reflect.Type]*openapi3.Schema
 }
 
-func newGenerator(info *openapi3.Info) *oaiGenerator {
+func newGenerator(info *openapi3.Info, schemaNamer SchemaNamer) *oaiGenerator {
 	return &oaiGenerator{
+		schemaNamer:     schemaNamer,
+		schemaTypes:     make(map[string]reflect.Type),
+		componentOwners: make(map[string]reflect.Type),
+		building:        make(map[reflect.Type]*openapi3.Schema),
 		openapi: &openapi3.T{
-			OpenAPI: "3.0.3",
+			OpenAPI: OpenAPIVersion30,
 			Info:    info,
+			Paths:   make(openapi3.Paths),
 			Components: openapi3.Components{
 				Schemas:       make(openapi3.Schemas),
 				Responses:     make(openapi3.Responses),
@@ -28,12 +42,65 @@ func newGenerator(info *openapi3.Info) *oaiGenerator {
 	}
 }
 
+// addError records a problem found in a field of model,
+// it is reported later by the operation being generated.
+func (g *oaiGenerator) addError(model reflect.Type, f *reflect.StructField, reason string) {
+	g.errs = append(g.errs, OpenAPISpecError{Field: model.Name() + "." + f.Name, Reason: reason})
+}
+
+// addNameError records that two distinct types have the same name of a kind of component.
+func (g *oaiGenerator) addNameError(kind, name string, owner, other reflect.Type) {
+	g.errs = append(g.errs, OpenAPISpecError{
+		Field:  name,
+		Reason: fmt.Sprintf("%s component name %q is used by both %s and %s", kind, name, qualifiedTypeName(owner), qualifiedTypeName(other)),
+	})
+}
+
+// qualifiedTypeName returns the name of t with its full package path.
+func qualifiedTypeName(t reflect.Type) string {
+	if t.Name() == "" || t.PkgPath() == "" {
+		return t.String()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
+
+// claimComponent reserves the name of a request body or response component for the model owner,
+// it returns false if another model has the name.
+func (g *oaiGenerator) claimComponent(kind, name string, owner reflect.Type) bool {
+	key := kind + "/" + name
+	if other, ok := g.componentOwners[key]; ok && other != owner {
+		g.addNameError(kind, name, other, owner)
+		return false
+	}
+	g.componentOwners[key] = owner
+	return true
+}
+
+// takeErrors returns the recorded problems and clears them.
+func (g *oaiGenerator) takeErrors() OpenAPISpecErrors {
+	errs := g.errs
+	g.errs = nil
+	return errs
+}
+
 func (g *oaiGenerator) GenerateJSONRequestBody(operationID string, model reflect.Type) *openapi3.RequestBodyRef {
-	schema := g.getSchemaRef(model, "json")
-	requestBody := openapi3.NewRequestBody().WithJSONSchemaRef(schema).WithRequired(true)
-	requestName := toCamelCase(operationID)
+	return g.GenerateRequestBody(operationID, model, map[string]string{fiber.MIMEApplicationJSON: "json"})
+}
 
-	// TODO: check if duplicate name
+// GenerateRequestBody generates a request body accepting the content types of nameTags,
+// which map each content type to the tag naming the properties of its schema.
+func (g *oaiGenerator) GenerateRequestBody(operationID string, model reflect.Type, nameTags map[string]string) *openapi3.RequestBodyRef {
+	content := g.generateContent(model, nameTags)
+	for contentType, mediaType := range content {
+		if contentType == fiber.MIMEMultipartForm {
+			mediaType.Encoding = g.generateEncoding(model, nameTags[contentType])
+		}
+	}
+	requestBody := openapi3.NewRequestBody().WithContent(content).WithRequired(true)
+	requestName := toCamelCase(operationID)
+	if !g.claimComponent("requestBody", requestName, model) {
+		return &openapi3.RequestBodyRef{Value: requestBody}
+	}
 	g.openapi.Components.RequestBodies[requestName] = &openapi3.RequestBodyRef{
 		Value: requestBody,
 	}
@@ -43,17 +110,74 @@ func (g *oaiGenerator) GenerateJSONRequestBody(operationID string, model reflect
 	}
 }
 
-func (g *oaiGenerator) GenerateResponse(operationID string, status int, model reflect.Type, typ string) *openapi3.ResponseRef {
-	ref := g.getSchemaRef(model, typ)
-	responseName := fmt.Sprintf("%s%s", toCamelCase(operationID), strings.ReplaceAll(http.StatusText(status), " ", ""))
-	response := openapi3.NewResponse().WithJSONSchemaRef(ref).WithDescription(http.StatusText(status))
+// generateEncoding collects the content types of multipart parts declared by the contentType prop.
+func (g *oaiGenerator) generateEncoding(t reflect.Type, nameTag string) map[string]*openapi3.Encoding {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	encoding := make(map[string]*openapi3.Encoding)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		field := newFieldResolver(&f)
+		if field.shouldEmbed() {
+			for k, v := range g.generateEncoding(f.Type, nameTag) {
+				encoding[k] = v
+			}
+			continue
+		}
+		if v, ok := field.tagPairs[PropContentType]; ok && !field.ignored {
+			encoding[field.name(nameTag)] = &openapi3.Encoding{ContentType: v}
+		}
+	}
+	if len(encoding) == 0 {
+		return nil
+	}
+	return encoding
+}
+
+// generateContent generates a media type of model for each content type of nameTags.
+func (g *oaiGenerator) generateContent(model reflect.Type, nameTags map[string]string) openapi3.Content {
+	content := make(openapi3.Content, len(nameTags))
+	for contentType, nameTag := range nameTags {
+		content[contentType] = openapi3.NewMediaType().WithSchemaRef(g.getSchemaRef(model, nameTag))
+	}
+	return content
+}
 
-	// TODO: check if has a duplicate name
+// GenerateResponse generates a response in the content types of nameTags,
+// which map each content type to the tag naming the properties of its schema.
+func (g *oaiGenerator) GenerateResponse(operationID string, status int, model reflect.Type, nameTags map[string]string) *openapi3.ResponseRef {
+	suffix := strings.ReplaceAll(http.StatusText(status), " ", "")
+	if status == 0 {
+		suffix = "Default"
+	}
+	responseName := toCamelCase(operationID) + suffix
+	response := openapi3.NewResponse().WithContent(g.generateContent(model, nameTags)).WithDescription(http.StatusText(status))
+	if !g.claimComponent("response", responseName, model) {
+		return &openapi3.ResponseRef{Value: response}
+	}
 	g.openapi.Components.Responses[responseName] = &openapi3.ResponseRef{Value: response}
 
 	return &openapi3.ResponseRef{Ref: fmt.Sprintf("#/components/responses/%s", responseName), Value: response}
 }
 
+// GenerateSharedResponse generates a response component which is referenced by many operations.
+func (g *oaiGenerator) GenerateSharedResponse(name, description string, model reflect.Type, contentType string) *openapi3.ResponseRef {
+	if _, ok := g.openapi.Components.Responses[name]; !ok {
+		ref := g.getSchemaRef(model, "json")
+		content := openapi3.Content{contentType: openapi3.NewMediaType().WithSchemaRef(ref)}
+		response := openapi3.NewResponse().WithContent(content).WithDescription(description)
+		g.openapi.Components.Responses[name] = &openapi3.ResponseRef{Value: response}
+	}
+	return &openapi3.ResponseRef{
+		Ref:   fmt.Sprintf("#/components/responses/%s", name),
+		Value: g.openapi.Components.Responses[name].Value,
+	}
+}
+
 func (g *oaiGenerator) GenerateParameters(model reflect.Type) openapi3.Parameters {
 	parameters := openapi3.NewParameters()
 	g.generateParameters(&parameters, model)
@@ -66,13 +190,7 @@ func (g *oaiGenerator) generateParameters(parameters *openapi3.Parameters, t ref
 	}
 
 	handleField := func(f *reflect.StructField) {
-		var typ string
-		for _, pos := range []string{openapi3.ParameterInQuery, openapi3.ParameterInHeader, openapi3.ParameterInPath, openapi3.ParameterInCookie} {
-			if name := f.Tag.Get(pos); name != "" {
-				typ = pos
-				break
-			}
-		}
+		typ := parameterLocation(f)
 		field := newFieldResolver(f)
 		if field.shouldEmbed() {
 			g.generateParameters(parameters, f.Type)
@@ -82,9 +200,20 @@ func (g *oaiGenerator) generateParameters(parameters *openapi3.Parameters, t ref
 			return
 		}
 		if typ == "" {
-			panic(fmt.Sprintf("field %q's parameter type is unknown", f.Name))
+			g.addError(t, f, `parameter location is unknown, one of the "query", "header", "path" or "cookie" tags is required`)
+			return
+		}
+		if err := field.check(); err != nil {
+			g.addError(t, f, err.Error())
+			return
 		}
 		fieldSchema, _ := g.genSchema(nil, f.Type, typ)
+		if schema, ok := parameterSchema(f.Type); ok {
+			fieldSchema = schema.NewRef()
+		}
+		if doc := fieldDescription(t, f.Index); doc != "" {
+			fieldSchema.Value.Description = doc
+		}
 		field.injectOAITags(fieldSchema.Value)
 		param := &openapi3.Parameter{
 			Required:    field.required(),
@@ -103,7 +232,14 @@ func (g *oaiGenerator) generateParameters(parameters *openapi3.Parameters, t ref
 			param.Style = v
 		}
 		if err := param.Validate(context.TODO()); err != nil {
-			panic(err)
+			g.addError(t, f, err.Error())
+			return
+		}
+		if sm, err := param.SerializationMethod(); err == nil {
+			if err := checkSerializationMethod(sm, f.Type); err != nil {
+				g.addError(t, f, err.Error())
+				return
+			}
 		}
 		*parameters = append(*parameters, &openapi3.ParameterRef{Value: param})
 	}
@@ -0,0 +1,173 @@
+package soda
+
+import (
+	"reflect"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Group is a set of operations sharing a path prefix and OpenAPI metadata.
+// Everything declared on a group is applied to its operations when they are registered by OK().
+type Group struct {
+	soda   *Soda
+	parent *Group
+	prefix string
+
+	tags             []string
+	handlers         []fiber.Handler
+	securityHandlers []fiber.Handler
+	jwtSecurity      bool
+	parameters       []reflect.Type
+	responses        []groupResponse
+}
+
+type groupResponse struct {
+	status int
+	model  interface{}
+}
+
+// Group creates a new group whose operations are prefixed by prefix and run the given handlers as middlewares.
+func (s *Soda) Group(prefix string, handlers ...fiber.Handler) *Group {
+	return &Group{
+		soda:     s,
+		prefix:   prefix,
+		handlers: handlers,
+	}
+}
+
+// Group creates a nested group, inheriting everything declared on g.
+func (g *Group) Group(prefix string, handlers ...fiber.Handler) *Group {
+	return &Group{
+		soda:     g.soda,
+		parent:   g,
+		prefix:   joinPath(g.prefix, prefix),
+		handlers: handlers,
+	}
+}
+
+// Use adds middlewares to all operations of the group.
+func (g *Group) Use(handlers ...fiber.Handler) *Group {
+	g.handlers = append(g.handlers, handlers...)
+	return g
+}
+
+// AddTags adds tags to all operations of the group.
+func (g *Group) AddTags(tags ...string) *Group {
+	g.tags = append(g.tags, tags...)
+	return g
+}
+
+// AddJWTSecurity requires JWT authentication for all operations of the group.
+func (g *Group) AddJWTSecurity(validators ...fiber.Handler) *Group {
+	g.jwtSecurity = true
+	g.securityHandlers = append(g.securityHandlers, validators...)
+	return g
+}
+
+// SetParameters declares parameters shared by all operations of the group.
+// The bound value is stored in fiber.Ctx.Locals under KeySharedParameter(model).
+func (g *Group) SetParameters(model interface{}) *Group {
+	g.parameters = append(g.parameters, reflect.TypeOf(model))
+	return g
+}
+
+// AddJSONResponse declares a response shared by all operations of the group,
+// operations declaring the same status code keep their own response.
+func (g *Group) AddJSONResponse(status int, model interface{}) *Group {
+	g.responses = append(g.responses, groupResponse{status: status, model: model})
+	return g
+}
+
+func (g *Group) Get(path string, handlers ...fiber.Handler) *Operation {
+	return g.Handle(path, "GET", handlers...)
+}
+func (g *Group) Post(path string, handlers ...fiber.Handler) *Operation {
+	return g.Handle(path, "POST", handlers...)
+}
+func (g *Group) Put(path string, handlers ...fiber.Handler) *Operation {
+	return g.Handle(path, "PUT", handlers...)
+}
+func (g *Group) Patch(path string, handlers ...fiber.Handler) *Operation {
+	return g.Handle(path, "PATCH", handlers...)
+}
+func (g *Group) Delete(path string, handlers ...fiber.Handler) *Operation {
+	return g.Handle(path, "DELETE", handlers...)
+}
+func (g *Group) Handle(path, method string, handlers ...fiber.Handler) *Operation {
+	op := g.soda.Handle(joinPath(g.prefix, path), method, handlers...)
+	op.group = g
+	return op
+}
+
+// chain returns the group and its ancestors, outermost first.
+func (g *Group) chain() []*Group {
+	var groups []*Group
+	for ; g != nil; g = g.parent {
+		groups = append([]*Group{g}, groups...)
+	}
+	return groups
+}
+
+// apply merges the metadata of the group and its ancestors into the operation.
+func (g *Group) apply(op *Operation) {
+	var (
+		tags             []string
+		handlers         []fiber.Handler
+		securityHandlers []fiber.Handler
+		jwtSecurity      bool
+	)
+	for _, group := range g.chain() {
+		tags = append(tags, group.tags...)
+		handlers = append(handlers, group.handlers...)
+		securityHandlers = append(securityHandlers, group.securityHandlers...)
+		jwtSecurity = jwtSecurity || group.jwtSecurity
+
+		for _, t := range group.parameters {
+			op.addSharedParameters(t)
+		}
+		for _, resp := range group.responses {
+			if op.Operation.Responses.Get(resp.status) == nil {
+				op.AddJSONResponse(resp.status, resp.model)
+			}
+		}
+	}
+
+	opTags := op.Operation.Tags
+	op.Operation.Tags = nil
+	op.AddTags(append(tags, opTags...)...)
+
+	if jwtSecurity {
+		if !op.hasSecurity(jwtSecuritySchemeName) {
+			op.AddJWTSecurity()
+		}
+		op.securityHandlers = append(securityHandlers, op.securityHandlers...)
+	}
+
+	op.handlers = append(handlers, op.handlers...)
+}
+
+// KeySharedParameter returns the fiber.Ctx.Locals key of the group parameters declared by model.
+func KeySharedParameter(model interface{}) string {
+	return sharedParameterKey(reflect.TypeOf(model))
+}
+
+func sharedParameterKey(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return KeyParameter + "::" + t.PkgPath() + "." + t.Name()
+}
+
+func joinPath(prefix, path string) string {
+	if len(path) == 0 || path == "/" {
+		if prefix == "" {
+			return "/"
+		}
+		return prefix
+	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
+	return utils.TrimRight(prefix, '/') + path
+}
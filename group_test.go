@@ -0,0 +1,109 @@
+package soda
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type groupAuth struct {
+	Token string `header:"X-Token"`
+}
+
+type groupUser struct {
+	ID int `path:"id"`
+}
+
+type groupError struct {
+	Message string `json:"message"`
+}
+
+func TestJoinPath(t *testing.T) {
+	tests := []struct {
+		prefix, path, want string
+	}{
+		{"", "", "/"},
+		{"", "/users", "/users"},
+		{"/api", "", "/api"},
+		{"/api", "/", "/api"},
+		{"/api", "users", "/api/users"},
+		{"/api/", "/users", "/api/users"},
+		{"/api", "/users/:id", "/api/users/:id"},
+	}
+	for _, tt := range tests {
+		if got := joinPath(tt.prefix, tt.path); got != tt.want {
+			t.Errorf("joinPath(%q, %q) = %q, want %q", tt.prefix, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGroupInheritsMetadata(t *testing.T) {
+	app := New("test", "1.0.0")
+	var calls []string
+	middleware := func(name string) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			calls = append(calls, name)
+			return c.Next()
+		}
+	}
+	api := app.Group("/api", middleware("api")).
+		AddTags("api").
+		AddJWTSecurity().
+		SetParameters(groupAuth{}).
+		AddJSONResponse(500, groupError{})
+	v1 := api.Group("/v1", middleware("v1")).AddTags("v1")
+
+	op := mustRegister(t, v1.Get("/users/:id", func(c *fiber.Ctx) error {
+		auth := c.Locals(KeySharedParameter(groupAuth{})).(*groupAuth)
+		return c.SendString(auth.Token)
+	}).SetParameters(groupUser{}).AddTags("users", "api"))
+
+	if op.Path != "/api/v1/users/:id" {
+		t.Errorf("path = %q", op.Path)
+	}
+	if want := []string{"api", "v1", "users"}; !reflect.DeepEqual(op.Operation.Tags, want) {
+		t.Errorf("tags = %v, want %v", op.Operation.Tags, want)
+	}
+	if !op.hasSecurity(jwtSecuritySchemeName) {
+		t.Error("the JWT security of the group is not inherited")
+	}
+	if op.Operation.Parameters.GetByInAndName("header", "X-Token") == nil {
+		t.Error("the shared parameters of the group are not documented")
+	}
+	if op.Operation.Responses.Get(500) == nil {
+		t.Error("the shared response of the group is not documented")
+	}
+	if app.OpenAPI().Paths.Find("/api/v1/users/{id}") == nil {
+		t.Error("the path is not in the specification")
+	}
+
+	resp := doRequest(t, app, "GET", "/api/v1/users/1", "", "X-Token", "secret")
+	if resp.status != 200 || resp.body != "secret" {
+		t.Errorf("response = %d %q", resp.status, resp.body)
+	}
+	if want := []string{"api", "v1"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("middlewares = %v, want %v", calls, want)
+	}
+}
+
+func TestGroupKeepsOperationResponses(t *testing.T) {
+	app := New("test", "1.0.0")
+	group := app.Group("/api").AddJSONResponse(404, groupError{})
+	op := mustRegister(t, group.Get("/users", func(c *fiber.Ctx) error { return nil }).
+		AddResponse(404, nil))
+	if content := op.Operation.Responses.Get(404).Value.Content; content != nil {
+		t.Errorf("the response of the operation is replaced by the one of the group: %v", content)
+	}
+}
+
+func TestAddTagsSkipsDuplicates(t *testing.T) {
+	app := New("test", "1.0.0")
+	op := app.Get("/", func(c *fiber.Ctx) error { return nil }).AddTags("a", "b", "a").AddTags("b", "c")
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(op.Operation.Tags, want) {
+		t.Errorf("tags = %v, want %v", op.Operation.Tags, want)
+	}
+	if n := len(app.OpenAPI().Tags); n != 3 {
+		t.Errorf("%d tags are declared, want 3", n)
+	}
+}
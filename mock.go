@@ -0,0 +1,260 @@
+package soda
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxMockDepth bounds the nesting of fake data, so that recursive schemas end.
+const maxMockDepth = 8
+
+// Mock answers the requests with the documented examples of the operation, or with fake data generated from
+// the schemas of its responses, instead of running its handler. The parameters and the request body are still
+// bound and validated. The Prefer header selects another documented response, such as "Prefer: code=404",
+// or a named example, such as "Prefer: example=admin".
+func (op *Operation) Mock() *Operation {
+	op.mock = true
+	return op
+}
+
+// documentation tags the operations serving the specification and its renderers,
+// which are served by their handlers in mock mode.
+func (op *Operation) documentation() *Operation {
+	op.noMock = true
+	return op.AddTags("Documentation")
+}
+
+func (op *Operation) mockHandler(c *fiber.Ctx) error {
+	prefer := parsePrefer(c.Get("Prefer"))
+	status, response, err := op.mockResponse(prefer["code"])
+	if err != nil {
+		return err
+	}
+	var offers []string
+	for ct := range response.Content {
+		// examples and fake data are JSON values
+		if codec, ok := op.Soda.Options.codecs[ct]; ok && codec.NameTag() == "json" {
+			offers = append(offers, ct)
+		}
+	}
+	if len(offers) == 0 {
+		return c.SendStatus(status)
+	}
+	sortOffers(offers)
+	ct := c.Accepts(offers...)
+	if ct == "" {
+		return fiber.ErrNotAcceptable
+	}
+	value, err := mockValue(response.Content[ct], prefer["example"])
+	if err != nil {
+		return err
+	}
+	data, err := op.Soda.Options.codecs[ct].Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, ct)
+	return c.Status(status).Send(data)
+}
+
+// parsePrefer returns the preferences of a Prefer header, such as "code=404, example=admin".
+func parsePrefer(header string) map[string]string {
+	prefer := make(map[string]string)
+	for _, item := range strings.Split(header, ",") {
+		// the parameters of a preference are ignored
+		pair := strings.SplitN(strings.Split(item, ";")[0], "=", 2)
+		if len(pair) == 2 {
+			prefer[strings.ToLower(strings.TrimSpace(pair[0]))] = strings.Trim(strings.TrimSpace(pair[1]), `"`)
+		}
+	}
+	return prefer
+}
+
+// mockResponse returns the documented response with status code, or the first successful response.
+func (op *Operation) mockResponse(code string) (int, *openapi3.Response, error) {
+	responses := op.Operation.Responses
+	if code != "" {
+		status, err := strconv.Atoi(code)
+		if err == nil {
+			if response := responses.Get(status); response != nil && response.Value != nil {
+				return status, response.Value, nil
+			}
+		}
+		return 0, nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("response %s is not documented", code))
+	}
+	var statuses []int
+	for key, response := range responses {
+		if status, err := strconv.Atoi(key); err == nil && response.Value != nil {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		if status >= 200 && status < 300 {
+			return status, responses.Get(status).Value, nil
+		}
+	}
+	if len(statuses) > 0 {
+		return statuses[0], responses.Get(statuses[0]).Value, nil
+	}
+	if response := responses.Default(); response != nil && response.Value != nil {
+		return fiber.StatusOK, response.Value, nil
+	}
+	return fiber.StatusOK, openapi3.NewResponse(), nil
+}
+
+// mockValue returns the example name of mediaType, or its first example, or fake data generated from its schema.
+func mockValue(mediaType *openapi3.MediaType, name string) (interface{}, error) {
+	if name != "" {
+		if example, ok := mediaType.Examples[name]; ok && example.Value != nil {
+			return example.Value.Value, nil
+		}
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("example %q is not documented", name))
+	}
+	if mediaType.Example != nil {
+		return mediaType.Example, nil
+	}
+	names := make([]string, 0, len(mediaType.Examples))
+	for name := range mediaType.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if example := mediaType.Examples[name]; example.Value != nil {
+			return example.Value.Value, nil
+		}
+	}
+	if mediaType.Schema == nil || mediaType.Schema.Value == nil {
+		return nil, nil
+	}
+	return fakeValue(mediaType.Schema.Value, 0), nil
+}
+
+// fakeValue generates a value of schema, honoring its example, default, enum, format and bounds.
+func fakeValue(schema *openapi3.Schema, depth int) interface{} { //nolint
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	}
+	for _, refs := range []openapi3.SchemaRefs{schema.OneOf, schema.AnyOf, schema.AllOf} {
+		if len(refs) > 0 && refs[0].Value != nil {
+			return fakeValue(refs[0].Value, depth)
+		}
+	}
+	switch schema.Type {
+	case TypeObject:
+		obj := make(map[string]interface{}, len(schema.Properties))
+		if depth >= maxMockDepth {
+			return obj
+		}
+		required := make(map[string]bool, len(schema.Required))
+		for _, name := range schema.Required {
+			required[name] = true
+		}
+		for name, prop := range schema.Properties {
+			// optional write-only properties are not part of responses
+			if prop.Value != nil && (!prop.Value.WriteOnly || required[name]) {
+				obj[name] = fakeValue(prop.Value, depth+1)
+			}
+		}
+		return obj
+	case TypeArray:
+		n := schema.MinItems
+		if n == 0 && depth+1 < maxMockDepth {
+			n = 1
+		}
+		if schema.MaxItems != nil && n > *schema.MaxItems {
+			n = *schema.MaxItems
+		}
+		items := make([]interface{}, 0, n)
+		for i := uint64(0); i < n && schema.Items != nil && schema.Items.Value != nil; i++ {
+			items = append(items, fakeValue(schema.Items.Value, depth+1))
+		}
+		return items
+	case TypeString:
+		return fakeString(schema)
+	case TypeInteger:
+		return int64(fakeNumber(schema, 1))
+	case TypeNumber:
+		return fakeNumber(schema, 1.5)
+	case TypeBoolean:
+		return true
+	}
+	return nil
+}
+
+// fakeString returns a string of the format of schema, of the length it allows.
+func fakeString(schema *openapi3.Schema) string {
+	s := "string"
+	switch schema.Format {
+	case "date-time":
+		s = "2006-01-02T15:04:05Z"
+	case "date":
+		s = "2006-01-02"
+	case "time":
+		s = "15:04:05"
+	case "email":
+		s = "user@example.com"
+	case "uri", "uri-reference", "iri":
+		s = "https://example.com"
+	case "hostname":
+		s = "example.com"
+	case "ipv4":
+		s = "192.0.2.1"
+	case "ipv6":
+		s = "2001:db8::1"
+	case "uuid":
+		s = "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "byte":
+		s = "c29kYQ=="
+	case "duration":
+		s = "P1D"
+	}
+	if n := int(schema.MinLength); len(s) < n {
+		s += strings.Repeat("x", n-len(s))
+	}
+	if schema.MaxLength != nil && uint64(len(s)) > *schema.MaxLength {
+		s = s[:*schema.MaxLength]
+	}
+	return s
+}
+
+// fakeNumber returns value moved within the bounds of schema, rounded to a multiple of its multipleOf.
+func fakeNumber(schema *openapi3.Schema, value float64) float64 {
+	step := 1.0
+	if schema.Type == TypeNumber {
+		step = 0.5
+	}
+	if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+		step = *schema.MultipleOf
+	}
+	if schema.Min != nil && (value < *schema.Min || (schema.ExclusiveMin && value == *schema.Min)) {
+		value = *schema.Min
+		if schema.ExclusiveMin {
+			value += step
+		}
+	}
+	if schema.Max != nil && (value > *schema.Max || (schema.ExclusiveMax && value == *schema.Max)) {
+		value = *schema.Max
+		if schema.ExclusiveMax {
+			value -= step
+		}
+	}
+	if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+		value = math.Ceil(value / *schema.MultipleOf) * *schema.MultipleOf
+	}
+	if schema.Type == TypeInteger {
+		value = math.Ceil(value)
+	}
+	return value
+}
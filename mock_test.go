@@ -0,0 +1,90 @@
+package soda
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type mockUser struct {
+	ID    int    `json:"id" oai:"minimum=10"`
+	Email string `json:"email" oai:"format=email"`
+	Tags  []int  `json:"tags" oai:"minItems=2"`
+}
+
+func TestMockMode(t *testing.T) {
+	app := New("test", "1.0.0", WithMockMode(),
+		WithOpenAPISpec("/openapi.json"), WithOpenAPISpecYAML("/openapi.yaml"), WithSwagger2Spec("/swagger.json"),
+		WithSwagger("/swagger"), WithRedoc("/redoc"), WithRapiDoc("/rapidoc"))
+	mustRegister(t, app.Get("/users", func(c *fiber.Ctx) error {
+		return fiber.ErrTeapot
+	}).AddJSONResponse(200, mockUser{}).AddJSONResponse(404, nil).
+		AddResponseExample(200, "admin", mockUser{ID: 42, Email: "admin@example.com", Tags: []int{1, 2}}))
+	mustRegister(t, app.Get("/roles", func(c *fiber.Ctx) error {
+		return fiber.ErrTeapot
+	}).AddJSONResponse(200, mockUser{}))
+
+	tests := []struct {
+		name        string
+		target      string
+		headers     []string
+		status      int
+		contentType string
+		want        string
+	}{
+		{"example", "/users", nil, 200, fiber.MIMEApplicationJSON, `{"email":"admin@example.com","id":42,"tags":[1,2]}`},
+		{"named example", "/users", []string{"Prefer", "example=admin"}, 200, fiber.MIMEApplicationJSON,
+			`{"email":"admin@example.com","id":42,"tags":[1,2]}`},
+		{"fake data", "/roles", nil, 200, fiber.MIMEApplicationJSON, `{"email":"user@example.com","id":10,"tags":[1,1]}`},
+		{"code", "/users", []string{"Prefer", "code=404"}, 404, fiber.MIMETextPlainCharsetUTF8, "Not Found"},
+		{"undocumented code", "/users", []string{"Prefer", "code=500"}, 400, fiber.MIMETextPlainCharsetUTF8, "response 500 is not documented"},
+		{"undocumented example", "/users", []string{"Prefer", "example=guest"}, 400, fiber.MIMETextPlainCharsetUTF8,
+			`example "guest" is not documented`},
+		// the documentation is served by its handlers
+		{"spec", "/openapi.json", nil, 200, fiber.MIMEApplicationJSONCharsetUTF8, `"openapi":"3.0.3"`},
+		{"yaml spec", "/openapi.yaml", nil, 200, MIMEApplicationYAML + "; charset=utf-8", "openapi: 3.0.3"},
+		{"swagger 2.0 spec", "/swagger.json", nil, 200, fiber.MIMEApplicationJSONCharsetUTF8, `"swagger":"2.0"`},
+		{"swagger", "/swagger", nil, 200, fiber.MIMETextHTML, "swagger-ui"},
+		{"redoc", "/redoc", nil, 200, fiber.MIMETextHTML, "redoc"},
+		{"rapidoc", "/rapidoc", nil, 200, fiber.MIMETextHTML, "rapi-doc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, app, "GET", tt.target, "", tt.headers...)
+			if resp.status != tt.status || !strings.Contains(resp.body, tt.want) {
+				t.Errorf("response = %d %s, want %d %s", resp.status, resp.body, tt.status, tt.want)
+			}
+			if ct := resp.header.Get(fiber.HeaderContentType); ct != tt.contentType {
+				t.Errorf("content type = %q, want %q", ct, tt.contentType)
+			}
+		})
+	}
+}
+
+func TestMockOperation(t *testing.T) {
+	app := New("test", "1.0.0", EnableValidateRequest())
+	mustRegister(t, app.Get("/mocked", func(c *fiber.Ctx) error {
+		return fiber.ErrTeapot
+	}).SetParameters(listQuery{}).AddJSONResponse(200, mockUser{}).Mock())
+	mustRegister(t, app.Get("/served", func(c *fiber.Ctx) error {
+		return fiber.ErrTeapot
+	}).AddJSONResponse(200, mockUser{}))
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+	}{
+		{"mocked", "/mocked?limit=1", 200},
+		{"input is still bound", "/mocked?limit=a", 400},
+		{"served", "/served", fiber.StatusTeapot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := doRequest(t, app, "GET", tt.target, ""); resp.status != tt.status {
+				t.Errorf("status = %d %s, want %d", resp.status, resp.body, tt.status)
+			}
+		})
+	}
+}
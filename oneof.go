@@ -0,0 +1,332 @@
+package soda
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// oneOf describes an interface implemented by several types, which are told apart by a discriminator property.
+type oneOf struct {
+	propertyName string
+	// mapping maps the values of the discriminator property to the implementations.
+	mapping map[string]reflect.Type
+}
+
+// oneOfs are the interfaces registered by RegisterOneOf, they are registered before the operations.
+var oneOfs = make(map[reflect.Type]*oneOf)
+
+// RegisterOneOf documents the interface pointed by iface as one of the types of mapping,
+// such as RegisterOneOf((*Payment)(nil), "type", map[string]interface{}{"card": Card{}, "bank": BankTransfer{}}).
+// The value of the JSON property propertyName tells which type a JSON request body holds, it is decoded accordingly.
+// It panics if iface is not a pointer to an interface or if a type does not implement it.
+func RegisterOneOf(iface interface{}, propertyName string, mapping map[string]interface{}) {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic(fmt.Sprintf("soda: RegisterOneOf expects a pointer to an interface, got %v", t))
+	}
+	t = t.Elem()
+	o := &oneOf{propertyName: propertyName, mapping: make(map[string]reflect.Type, len(mapping))}
+	for value, model := range mapping {
+		mt := reflect.TypeOf(model)
+		if mt == nil || !mt.Implements(t) {
+			panic(fmt.Sprintf("soda: %v does not implement %v", mt, t))
+		}
+		o.mapping[value] = mt
+	}
+	oneOfs[t] = o
+}
+
+// values returns the values of the discriminator property in order.
+func (o *oneOf) values() []string {
+	values := make([]string, 0, len(o.mapping))
+	for value := range o.mapping {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// genOneOfSchema generates the oneOf schema of a registered interface and the schemas of its implementations as components.
+// Each implementation is wrapped in a component restricting the discriminator property to its value,
+// so that the component of the implementation is left as it is.
+func (g *oaiGenerator) genOneOfSchema(t reflect.Type, o *oneOf, nameTag string) *openapi3.SchemaRef {
+	name := g.getComponentName(t, nameTag)
+	// implementations may hold the interface as well
+	if ref, ok := g.openapi.Components.Schemas[name]; ok {
+		fieldSchema := *ref.Value
+		return openapi3.NewSchemaRef("#/components/schemas/"+name, &fieldSchema)
+	}
+	schema := openapi3.NewSchema()
+	g.openapi.Components.Schemas[name] = schema.NewRef()
+	schema.Discriminator = &openapi3.Discriminator{PropertyName: o.propertyName, Mapping: make(map[string]string, len(o.mapping))}
+	for _, value := range o.values() {
+		ref := g.getSchemaRef(o.mapping[value], nameTag)
+		if prop, ok := ref.Value.Properties[o.propertyName]; !ok || prop.Value == nil || prop.Value.Type != TypeString {
+			g.errs = append(g.errs, OpenAPISpecError{
+				Field:  o.mapping[value].String(),
+				Reason: fmt.Sprintf("the discriminator property %q of %v is not a string property", o.propertyName, t),
+			})
+		}
+		wrapper := g.genOneOfWrapper(t, o, value, ref, nameTag)
+		schema.OneOf = append(schema.OneOf, wrapper)
+		schema.Discriminator.Mapping[value] = wrapper.Ref
+	}
+	// a copy, so that the props of a field do not change the component
+	fieldSchema := *schema
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, &fieldSchema)
+}
+
+// genOneOfWrapper generates the component of the implementation ref of t whose discriminator property is value,
+// it is named after the interface and the value, such as PaymentCard.
+func (g *oaiGenerator) genOneOfWrapper(t reflect.Type, o *oneOf, value string, ref *openapi3.SchemaRef, nameTag string) *openapi3.SchemaRef {
+	name := g.getSchemaName(t) + toCamelCase(value)
+	if owner, ok := g.schemaTypes[name]; ok && owner != t {
+		g.addNameError("schema", name, owner, t)
+	}
+	g.schemaTypes[name] = t
+	if nameTag != "json" {
+		name += toCamelCase(nameTag)
+	}
+	discriminator := openapi3.NewObjectSchema()
+	discriminator.Properties = openapi3.Schemas{
+		o.propertyName: openapi3.NewStringSchema().WithEnum(value).NewRef(),
+	}
+	schema := openapi3.NewAllOfSchema()
+	schema.AllOf = openapi3.SchemaRefs{ref, discriminator.NewRef()}
+	g.openapi.Components.Schemas[name] = schema.NewRef()
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, schema)
+}
+
+// hasOneOf tells if values of t may hold registered interfaces in their fields, items or pointers.
+func hasOneOf(t reflect.Type) bool {
+	return hasOneOfIn(t, nil)
+}
+
+func hasOneOfIn(t reflect.Type, parents []reflect.Type) bool {
+	t = indirectType(t)
+	for _, parent := range parents {
+		if parent == t {
+			return false
+		}
+	}
+	parents = append(parents, t)
+	switch t.Kind() {
+	case reflect.Interface:
+		_, ok := oneOfs[t]
+		return ok
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return hasOneOfIn(t.Elem(), parents)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.IsExported() && hasOneOfIn(f.Type, parents) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// prepareOneOfs sets the registered interfaces of v to pointers to the implementations named by
+// their discriminator property in data, so that encoding/json decodes the implementations in place.
+// encoding/json replaces the elements of maps, so maps are decoded here and left out of the data it returns.
+func prepareOneOfs(data []byte, v reflect.Value, path string) ([]byte, error) {
+	if string(data) == "null" {
+		return data, nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !hasOneOf(v.Type()) {
+			return data, nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return prepareOneOfs(data, v.Elem(), path)
+	case reflect.Interface:
+		if o, ok := oneOfs[v.Type()]; ok {
+			return prepareOneOf(data, v, o, path)
+		}
+	case reflect.Struct:
+		if !hasOneOf(v.Type()) {
+			return data, nil
+		}
+		var props map[string]json.RawMessage
+		// malformed bodies are reported by encoding/json
+		if json.Unmarshal(data, &props) != nil {
+			return data, nil
+		}
+		changed, err := prepareFieldOneOfs(props, v, path)
+		if err != nil || !changed {
+			return data, err
+		}
+		return json.Marshal(props)
+	case reflect.Slice, reflect.Array:
+		if !hasOneOf(v.Type().Elem()) {
+			return data, nil
+		}
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return data, nil
+		}
+		if v.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(v.Type(), len(items), len(items)))
+		}
+		changed := false
+		for i := 0; i < len(items) && i < v.Len(); i++ {
+			item, err := prepareOneOfs(items[i], v.Index(i), joinFieldPath(path, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			changed = changed || !bytes.Equal(item, items[i])
+			items[i] = item
+		}
+		if !changed {
+			return data, nil
+		}
+		return json.Marshal(items)
+	case reflect.Map:
+		if !hasOneOf(v.Type().Elem()) || v.Type().Key().Kind() != reflect.String {
+			return data, nil
+		}
+		var entries map[string]json.RawMessage
+		if json.Unmarshal(data, &entries) != nil {
+			return data, nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(entries)))
+		}
+		for _, key := range sortedKeys(entries) {
+			elem := reflect.New(v.Type().Elem())
+			if err := decodeOneOfs(entries[key], elem, joinFieldPath(path, key)); err != nil {
+				return nil, err
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem.Elem())
+		}
+		// decoding an empty object keeps the elements of the map
+		return []byte("{}"), nil
+	}
+	return data, nil
+}
+
+// prepareFieldOneOfs prepares the fields of v holding registered interfaces,
+// it rewrites props and tells whether one of them changed.
+func prepareFieldOneOfs(props map[string]json.RawMessage, v reflect.Value, path string) (bool, error) {
+	changed := false
+	for _, f := range structFields(v.Type(), "json") {
+		if !hasOneOf(f.Type) {
+			continue
+		}
+		if raw, ok := props[f.name]; ok {
+			prop, err := prepareOneOfs(raw, fieldByIndex(v, f.Index), joinFieldPath(path, f.name))
+			if err != nil {
+				return false, err
+			}
+			changed = changed || !bytes.Equal(prop, raw)
+			props[f.name] = prop
+		}
+	}
+	return changed, nil
+}
+
+func prepareOneOf(data []byte, v reflect.Value, o *oneOf, path string) ([]byte, error) {
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(data, &props); err != nil {
+		return nil, ValidationErrors{NewValidationError(PositionBody, path, "invalid value, expected object")}
+	}
+	var value string
+	if raw, ok := props[o.propertyName]; !ok || json.Unmarshal(raw, &value) != nil {
+		return nil, ValidationErrors{NewValidationError(PositionBody, joinFieldPath(path, o.propertyName), "is required")}
+	}
+	t, ok := o.mapping[value]
+	if !ok {
+		reason := fmt.Sprintf("invalid value %q, expected one of %v", value, o.values())
+		return nil, ValidationErrors{NewValidationError(PositionBody, joinFieldPath(path, o.propertyName), reason)}
+	}
+	impl := reflect.New(indirectType(t))
+	data, err := prepareOneOfs(data, impl.Elem(), path)
+	if err != nil {
+		return nil, err
+	}
+	v.Set(impl)
+	return data, nil
+}
+
+// settleOneOfs replaces the pointers set by prepareOneOfs by the values they point to,
+// unless the implementations were registered as pointers.
+func settleOneOfs(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() && hasOneOf(v.Type()) {
+			settleOneOfs(v.Elem())
+		}
+	case reflect.Interface:
+		o, ok := oneOfs[v.Type()]
+		if !ok || v.IsNil() || v.Elem().Kind() != reflect.Ptr {
+			return
+		}
+		impl := v.Elem()
+		settleOneOfs(impl.Elem())
+		for _, t := range o.mapping {
+			if t == impl.Type().Elem() {
+				v.Set(impl.Elem())
+				return
+			}
+		}
+	case reflect.Struct:
+		if !hasOneOf(v.Type()) {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				settleOneOfs(v.Field(i))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if !hasOneOf(v.Type().Elem()) {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			settleOneOfs(v.Index(i))
+		}
+	}
+}
+
+// unmarshalOneOfs decodes a JSON body holding registered interfaces into out.
+func unmarshalOneOfs(codec Codec, data []byte, out interface{}) error {
+	v := reflect.ValueOf(out)
+	data, err := prepareOneOfs(data, v, "")
+	if err != nil {
+		return err
+	}
+	if err := codec.Unmarshal(data, out); err != nil {
+		return err
+	}
+	settleOneOfs(v)
+	return nil
+}
+
+// decodeOneOfs decodes the JSON value data holding registered interfaces into the value pointed by ptr.
+func decodeOneOfs(data []byte, ptr reflect.Value, path string) error {
+	data, err := prepareOneOfs(data, ptr.Elem(), path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return err
+	}
+	settleOneOfs(ptr.Elem())
+	return nil
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
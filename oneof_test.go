@@ -0,0 +1,148 @@
+package soda
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
+)
+
+type testPayment interface{ isTestPayment() }
+
+type testCard struct {
+	Type   string `json:"type"`
+	Number string `json:"number"`
+}
+
+func (testCard) isTestPayment() {}
+
+type testBank struct {
+	Type string `json:"type"`
+	IBAN string `json:"iban"`
+}
+
+func (*testBank) isTestPayment() {}
+
+type testOrder struct {
+	Payment  testPayment            `json:"payment"`
+	Payments []testPayment          `json:"payments,omitempty"`
+	ByName   map[string]testPayment `json:"by_name,omitempty"`
+	Backup   *testPayment           `json:"backup,omitempty"`
+}
+
+func registerTestPayment() {
+	RegisterOneOf((*testPayment)(nil), "type", map[string]interface{}{"card": testCard{}, "bank": &testBank{}})
+}
+
+func TestOneOfSchema(t *testing.T) {
+	registerTestPayment()
+	g := newGenerator(&openapi3.Info{}, nil)
+	ref := g.getSchemaRef(reflect.TypeOf(testOrder{}), "json")
+	if errs := g.takeErrors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	schemas := g.openapi.Components.Schemas
+	payment := schemas["sodaTestPayment"]
+	if payment == nil {
+		t.Fatalf("components = %v", sortedKeys(schemas))
+	}
+	tests := []struct {
+		name string
+		got  interface{}
+		want string
+	}{
+		{"oneOf", payment.Value.OneOf,
+			`[{"$ref":"#/components/schemas/sodaTestPaymentBank"},{"$ref":"#/components/schemas/sodaTestPaymentCard"}]`},
+		{"discriminator", payment.Value.Discriminator,
+			`{"mapping":{"bank":"#/components/schemas/sodaTestPaymentBank","card":"#/components/schemas/sodaTestPaymentCard"},"propertyName":"type"}`},
+		{"wrapper", schemas["sodaTestPaymentCard"].Value,
+			`{"allOf":[{"$ref":"#/components/schemas/sodaTestCard"},{"properties":{"type":{"enum":["card"],"type":"string"}},"type":"object"}]}`},
+		{"implementation", schemas["sodaTestCard"].Value.Properties["type"].Value, `{"type":"string"}`},
+		{"map values", ref.Value.Properties["by_name"].Value.AdditionalProperties,
+			`{"$ref":"#/components/schemas/sodaTestPayment"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toJSON(t, tt.got); got != tt.want {
+				t.Errorf("schema = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalOneOfs(t *testing.T) {
+	registerTestPayment()
+	var backup testPayment = testCard{Type: "card", Number: "2"}
+	tests := []struct {
+		name    string
+		body    string
+		want    testOrder
+		wantErr string
+	}{
+		{"value", `{"payment":{"type":"card","number":"1"}}`,
+			testOrder{Payment: testCard{Type: "card", Number: "1"}}, ""},
+		{"pointer implementation", `{"payment":{"type":"bank","iban":"DE1"}}`,
+			testOrder{Payment: &testBank{Type: "bank", IBAN: "DE1"}}, ""},
+		{"items", `{"payment":null,"payments":[{"type":"card","number":"1"},{"type":"bank","iban":"DE1"}]}`,
+			testOrder{Payments: []testPayment{testCard{Type: "card", Number: "1"}, &testBank{Type: "bank", IBAN: "DE1"}}}, ""},
+		{"map values", `{"payment":null,"by_name":{"a":{"type":"card","number":"1"},"b":{"type":"bank","iban":"DE1"}}}`,
+			testOrder{ByName: map[string]testPayment{"a": testCard{Type: "card", Number: "1"}, "b": &testBank{Type: "bank", IBAN: "DE1"}}}, ""},
+		{"pointer to interface", `{"payment":null,"backup":{"type":"card","number":"2"}}`,
+			testOrder{Backup: &backup}, ""},
+		{"missing discriminator", `{"payment":{"number":"1"}}`, testOrder{}, "payment.type is required"},
+		{"invalid discriminator", `{"payment":{"type":"cash"}}`, testOrder{},
+			`payment.type invalid value "cash", expected one of [bank card]`},
+		{"invalid map value", `{"by_name":{"a":{"type":"cash"}}}`, testOrder{},
+			`by_name.a.type invalid value "cash", expected one of [bank card]`},
+		{"not an object", `{"payments":[1]}`, testOrder{}, "payments.0 invalid value, expected object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testOrder
+			err := unmarshalOneOfs(jsonCodec{}, []byte(tt.body), &got)
+			if tt.wantErr != "" {
+				var errs ValidationErrors
+				if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field+" "+errs[0].Reason != tt.wantErr {
+					t.Errorf("err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOneOfRequestBody(t *testing.T) {
+	registerTestPayment()
+	app := New("test", "1.0.0", EnableOpenAPIRequestValidation())
+	mustRegister(t, app.Post("/orders", func(c *fiber.Ctx) error {
+		order := c.Locals(KeyRequestBody).(*testOrder)
+		return c.SendString(reflect.TypeOf(order.ByName["a"]).String())
+	}).SetRequestBody(testOrder{}, fiber.MIMEApplicationJSON))
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{"map value", `{"payment":{"type":"card","number":"1"},"by_name":{"a":{"type":"bank","iban":"DE1"}}}`, 200, "*soda.testBank"},
+		{"mismatched discriminator", `{"payment":{"type":"card","iban":"DE1"}}`, 400, "payment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, app, "POST", "/orders", tt.body, fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if resp.status != tt.status || !strings.Contains(resp.body, tt.want) {
+				t.Errorf("response = %d %s, want %d %s", resp.status, resp.body, tt.status, tt.want)
+			}
+		})
+	}
+}
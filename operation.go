@@ -2,6 +2,7 @@ package soda
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"reflect"
@@ -21,8 +22,29 @@ type Operation struct {
 	TRequestBody reflect.Type
 	Soda         *Soda
 
+	group            *Group
+	errs             OpenAPISpecErrors
+	sharedParameters []reflect.Type
+	binders          []*parameterBinder
+	// bodyDefaults are the default values of the request body by the tag naming its properties.
+	bodyDefaults     map[string][]fieldDefault
+	bodyOneOf        bool
+	validation       *bool
+	validator        *validator.Validate
 	securityHandlers []fiber.Handler
 	handlers         []fiber.Handler
+	requestExamples  []namedExample
+	responseExamples map[int][]namedExample
+	mock             bool
+	// noMock keeps the handler of the operation in mock mode, see documentation.
+	noMock bool
+	// successStatus and successModel document the response of a typed handler, see Handle.
+	successStatus int
+	successModel  interface{}
+	// handler is the function whose doc comment describes the operation, the last of handlers by default.
+	handler interface{}
+	// placeholder is the default response documenting operations without responses, see declaredDefault.
+	placeholder *openapi3.Response
 }
 
 func (op *Operation) SetDescription(desc string) *Operation {
@@ -43,42 +65,153 @@ func (op *Operation) SetOperationID(id string) *Operation {
 func (op *Operation) SetParameters(model interface{}) *Operation {
 	op.TParameters = reflect.TypeOf(model)
 	op.Operation.Parameters = op.Soda.oaiGenerator.GenerateParameters(op.TParameters)
+	op.collectErrors()
 	return op
 }
 
+// SetValidation enables or disables the validation of the operation's parameters and request body,
+// overriding EnableValidateRequest. The input is bound either way.
+func (op *Operation) SetValidation(enabled bool) *Operation {
+	op.validation = &enabled
+	return op
+}
+
+// SetValidator validates the operation's parameters and request body with v
+// instead of the validator given to EnableValidateRequest.
+func (op *Operation) SetValidator(v *validator.Validate) *Operation {
+	op.validator = v
+	return op.SetValidation(true)
+}
+
+// inputValidator returns the validator of the operation's input, nil when the validation is disabled.
+func (op *Operation) inputValidator() *validator.Validate {
+	switch {
+	case op.validation != nil && !*op.validation:
+		return nil
+	case op.validator != nil:
+		return op.validator
+	case op.Soda.Options.validator != nil:
+		return op.Soda.Options.validator
+	case op.validation != nil:
+		return validator.New()
+	}
+	return nil
+}
+
 func (op *Operation) AddJWTSecurity(validators ...fiber.Handler) *Operation {
 	op.securityHandlers = append(op.securityHandlers, validators...)
 	if len(op.Soda.oaiGenerator.openapi.Components.SecuritySchemes) == 0 {
 		op.Soda.oaiGenerator.openapi.Components.SecuritySchemes = make(map[string]*openapi3.SecuritySchemeRef, 1)
 	}
-	op.Soda.oaiGenerator.openapi.Components.SecuritySchemes["JWTAuth"] = &openapi3.SecuritySchemeRef{Value: openapi3.NewJWTSecurityScheme()}
+	op.Soda.oaiGenerator.openapi.Components.SecuritySchemes[jwtSecuritySchemeName] = &openapi3.SecuritySchemeRef{Value: openapi3.NewJWTSecurityScheme()}
 	if op.Operation.Security == nil {
 		op.Operation.Security = openapi3.NewSecurityRequirements()
 	}
-	require := openapi3.NewSecurityRequirement().Authenticate("JWTAuth")
+	require := openapi3.NewSecurityRequirement().Authenticate(jwtSecuritySchemeName)
 	op.Operation.Security.With(require)
 	return op
 }
 
-func (op *Operation) SetJSONRequestBody(model interface{}) *Operation {
+func (op *Operation) hasSecurity(name string) bool {
+	if op.Operation.Security == nil {
+		return false
+	}
+	for _, requirement := range *op.Operation.Security {
+		if _, ok := requirement[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// addSharedParameters documents the parameters of a group on the operation,
+// parameters already declared by the operation itself are kept.
+func (op *Operation) addSharedParameters(t reflect.Type) {
+	op.sharedParameters = append(op.sharedParameters, t)
+	for _, p := range op.Soda.oaiGenerator.GenerateParameters(t) {
+		if op.Operation.Parameters.GetByInAndName(p.Value.In, p.Value.Name) == nil {
+			op.Operation.Parameters = append(op.Operation.Parameters, p)
+		}
+	}
+	op.collectErrors()
+}
+
+// collectErrors takes the problems recorded while generating the operation's specification.
+func (op *Operation) collectErrors() {
+	for _, err := range op.Soda.oaiGenerator.takeErrors() {
+		op.addError(err.Field, err.Reason)
+	}
+}
+
+func (op *Operation) addError(field, reason string) {
+	op.errs = append(op.errs, OpenAPISpecError{
+		Position: op.Method + " " + fixPath(op.Path),
+		Field:    field,
+		Reason:   reason,
+	})
+}
+
+// SetRequestBody declares a request body accepted in any of contentTypes, application/json by default.
+// The body is decoded by the codec of its Content-Type, other content types are answered with 415.
+func (op *Operation) SetRequestBody(model interface{}, contentTypes ...string) *Operation {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{fiber.MIMEApplicationJSON}
+	}
 	op.TRequestBody = reflect.TypeOf(model)
-	op.Operation.RequestBody = op.Soda.oaiGenerator.GenerateJSONRequestBody(op.Operation.OperationID, op.TRequestBody)
+	op.Operation.RequestBody = op.Soda.oaiGenerator.GenerateRequestBody(op.Operation.OperationID, op.TRequestBody, op.nameTags(contentTypes))
+	op.collectErrors()
 	return op
 }
 
+func (op *Operation) SetJSONRequestBody(model interface{}) *Operation {
+	return op.SetRequestBody(model, fiber.MIMEApplicationJSON)
+}
+
+// SetFormRequestBody declares an application/x-www-form-urlencoded request body whose fields are named after the form tag.
+func (op *Operation) SetFormRequestBody(model interface{}) *Operation {
+	return op.SetRequestBody(model, fiber.MIMEApplicationForm)
+}
+
+// SetMultipartRequestBody declares a multipart/form-data request body whose parts are named after the form tag,
+// *multipart.FileHeader and []*multipart.FileHeader fields are file uploads.
+// The oai props maxLength and contentType of file fields limit their size and content types.
+func (op *Operation) SetMultipartRequestBody(model interface{}) *Operation {
+	return op.SetRequestBody(model, fiber.MIMEMultipartForm)
+}
+
 func (op *Operation) AddJSONResponse(status int, model interface{}) *Operation {
+	return op.AddResponse(status, model, fiber.MIMEApplicationJSON)
+}
+
+// AddResponse documents a response in any of contentTypes, application/json by default.
+// Status 0 documents the default response.
+// Respond encodes the response in the content type preferred by the Accept header.
+func (op *Operation) AddResponse(status int, model interface{}, contentTypes ...string) *Operation {
+	if len(contentTypes) == 0 {
+		contentTypes = []string{fiber.MIMEApplicationJSON}
+	}
 	if len(op.Operation.Responses) == 0 {
 		op.Operation.Responses = make(openapi3.Responses)
 	}
 	if model != nil {
-		ref := op.Soda.oaiGenerator.GenerateResponse(op.Operation.OperationID, status, reflect.TypeOf(model), "json")
-		op.Operation.Responses[strconv.Itoa(status)] = ref
+		ref := op.Soda.oaiGenerator.GenerateResponse(op.Operation.OperationID, status, reflect.TypeOf(model), op.nameTags(contentTypes))
+		op.Operation.Responses[responseKey(status)] = ref
+		op.collectErrors()
 	} else {
 		op.Operation.AddResponse(status, openapi3.NewResponse().WithDescription(http.StatusText(status)))
 	}
 	return op
 }
 
+// responseKey returns the key of the response with status in the responses of an operation,
+// status 0 being the default response.
+func responseKey(status int) string {
+	if status == 0 {
+		return "default"
+	}
+	return strconv.Itoa(status)
+}
+
 func (op *Operation) AddResponseWithContentType(status int, contentType string) *Operation {
 	if len(op.Operation.Responses) == 0 {
 		op.Operation.Responses = make(openapi3.Responses)
@@ -88,9 +221,12 @@ func (op *Operation) AddResponseWithContentType(status int, contentType string)
 	return op
 }
 
+// AddTags tags the operation, tags it already has are skipped.
 func (op *Operation) AddTags(tags ...string) *Operation {
-	op.Operation.Tags = append(op.Operation.Tags, tags...)
 	for _, tag := range tags {
+		if !containsString(op.Operation.Tags, tag) {
+			op.Operation.Tags = append(op.Operation.Tags, tag)
+		}
 		if t := op.Soda.oaiGenerator.openapi.Tags.Get(tag); t == nil {
 			op.Soda.oaiGenerator.openapi.Tags = append(op.Soda.oaiGenerator.openapi.Tags, &openapi3.Tag{Name: tag})
 		}
@@ -103,63 +239,142 @@ func (op *Operation) SetDeprecated(deprecated bool) *Operation {
 	return op
 }
 
+// SetSuccessStatus sets the status code a typed handler responds with, such as 201 for an operation creating a resource.
+// It is only used by the operations registered by Handle, Get, Post, Put, Patch and Delete.
+func (op *Operation) SetSuccessStatus(status int) *Operation {
+	if op.successModel == nil {
+		op.addError("", "the success status is only used by typed handlers")
+		return op
+	}
+	op.successStatus = status
+	return op
+}
+
+// OK registers the operation like Register does, but exits if the operation is invalid.
 func (op *Operation) OK() *Operation {
-	if err := op.Operation.Validate(context.TODO()); err != nil {
+	if err := op.Register(); err != nil {
 		log.Fatalln(err)
 	}
+	return op
+}
+
+// Register validates the operation, then adds it to the OpenAPI specification and to the fiber app.
+// All the problems found are returned as OpenAPISpecErrors and are also reported by Soda.Build.
+func (op *Operation) Register() error {
+	if op.group != nil {
+		op.group.apply(op)
+	}
+	op.describe()
+	if op.successModel != nil {
+		op.AddJSONResponse(op.successStatus, op.successModel)
+	}
+	op.addValidationErrorResponse()
+	op.addProblemResponse()
+	// examples may be given for the responses added by soda
+	op.addExamples()
+	if err := op.Operation.Validate(context.TODO()); err != nil {
+		op.addError("", err.Error())
+	}
+	// the names of the request body and response components are derived from the operation id
+	for path, item := range op.Soda.oaiGenerator.openapi.Paths {
+		for method, other := range item.Operations() {
+			if other.OperationID == op.Operation.OperationID {
+				op.addError("", fmt.Sprintf("operation id %q is used by %s %s as well", other.OperationID, method, path))
+			}
+		}
+	}
+	pathItem := &openapi3.PathItem{}
+	pathItem.SetOperation(op.Method, op.Operation)
+	if err := (openapi3.Paths{fixPath(op.Path): pathItem}).Validate(context.TODO()); err != nil {
+		op.addError("", err.Error())
+	}
+	if len(op.errs) > 0 {
+		op.Soda.errs = append(op.Soda.errs, op.errs...)
+		return op.errs
+	}
 
 	op.Soda.oaiGenerator.openapi.AddOperation(fixPath(op.Path), op.Method, op.Operation)
-	if err := op.Soda.oaiGenerator.openapi.Validate(context.TODO()); err != nil {
-		log.Fatalln(err)
+	op.compileBinders()
+	op.validator = op.inputValidator()
+	handler := op.handlers[len(op.handlers)-1]
+	if (op.mock || op.Soda.Options.mock) && !op.noMock {
+		handler = op.mockHandler
 	}
-	op.handlers = append(op.handlers[:len(op.handlers)-1], BindData(op), op.handlers[len(op.handlers)-1])
+	op.handlers = append(op.handlers[:len(op.handlers)-1], BindData(op), handler)
 	op.Soda.Add(op.Method, op.Path, op.handlers...)
-	return op
+	return nil
 }
 
-func (op *Operation) parameterParsers() []parserFunc {
-	if op.Operation.Parameters == nil {
-		return nil
+// describe documents the operation with the doc comment of its handler, unless SetSummary or SetDescription did.
+func (op *Operation) describe() {
+	handler := op.handler
+	if handler == nil && len(op.handlers) > 0 {
+		handler = op.handlers[len(op.handlers)-1]
+	}
+	summary, description := describeHandler(handler)
+	// the summary defaults to the method and the path
+	if summary != "" && op.Operation.Summary == op.Method+" "+op.Path {
+		op.Operation.Summary = summary
 	}
-	set := make(map[string]struct{})
-	for _, p := range op.Operation.Parameters {
-		set[p.Value.In] = struct{}{}
+	if description != "" && op.Operation.Description == "" {
+		op.Operation.Description = description
 	}
-	funcs := make([]parserFunc, 0, len(set))
-	for k := range set {
-		if fn, ok := parameterParsers[k]; ok {
-			funcs = append(funcs, fn)
+}
+
+// bindsInput tells if the operation declares parameters or a request body.
+func (op *Operation) bindsInput() bool {
+	return op.TParameters != nil || op.TRequestBody != nil || len(op.sharedParameters) > 0
+}
+
+// compileBinders compiles the binders of the shared parameters and of the operation's own parameters,
+// and the default values of the request body.
+func (op *Operation) compileBinders() {
+	if op.TRequestBody != nil {
+		op.bodyDefaults = make(map[string][]fieldDefault)
+		for contentType := range op.Operation.RequestBody.Value.Content {
+			if tag := op.contentNameTag(contentType); op.bodyDefaults[tag] == nil {
+				op.bodyDefaults[tag] = compileDefaults(op.TRequestBody, tag, nil)
+			}
 		}
+		op.bodyOneOf = hasOneOf(op.TRequestBody)
+	}
+	for _, t := range op.sharedParameters {
+		op.binders = append(op.binders, compileParameterBinder(t, sharedParameterKey(t), op.Operation.Parameters))
+	}
+	if op.TParameters != nil {
+		op.binders = append(op.binders, compileParameterBinder(op.TParameters, KeyParameter, op.Operation.Parameters))
 	}
-	return funcs
 }
 
 func (op *Operation) bindParameter(c *fiber.Ctx, v *validator.Validate) error {
-	if op.TParameters != nil {
-		parameters := reflect.New(op.TParameters).Interface()
-		for _, parser := range op.parameterParsers() {
-			if err := parser(c, parameters); err != nil {
-				return err
-			}
+	for _, b := range op.binders {
+		parameters, errs := b.bind(c)
+		if len(errs) > 0 {
+			return errs
 		}
-		if op.TParameters.Kind() == reflect.Struct {
+		if v != nil && b.typ.Kind() == reflect.Struct {
 			if err := v.StructCtx(c.Context(), parameters); err != nil {
-				return err
+				return convertValidatorError(b.typ, "", err, parameterPositions...)
 			}
 		}
-		c.Locals(KeyParameter, parameters)
+		c.Locals(b.key, parameters)
 	}
 	return nil
 }
+
 func (op *Operation) bindBody(c *fiber.Ctx, v *validator.Validate) error {
 	if op.TRequestBody != nil {
 		requestBody := reflect.New(op.TRequestBody).Interface()
-		if err := c.BodyParser(&requestBody); err != nil {
-			return err
+		// absent fields keep their defaults
+		defaults := op.bodyDefaults[op.bodyNameTag(c)]
+		prefillDefaults(reflect.ValueOf(requestBody).Elem(), defaults)
+		if err := op.parseBody(c, requestBody); err != nil {
+			return convertBodyError(err)
 		}
-		if op.TRequestBody.Kind() == reflect.Struct {
+		applyArrayDefaults(reflect.ValueOf(requestBody).Elem(), defaults)
+		if v != nil && op.TRequestBody.Kind() == reflect.Struct {
 			if err := v.StructCtx(c.Context(), requestBody); err != nil {
-				return err
+				return convertValidatorError(op.TRequestBody, PositionBody, err, op.bodyNameTag(c))
 			}
 		}
 		c.Locals(KeyRequestBody, requestBody)
@@ -167,26 +382,70 @@ func (op *Operation) bindBody(c *fiber.Ctx, v *validator.Validate) error {
 	return nil
 }
 
+// addValidationErrorResponse documents the response rendered when the operation's input is invalid.
+func (op *Operation) addValidationErrorResponse() {
+	status := op.Soda.Options.validationErrorStatus
+	if !op.bindsInput() || op.Operation.Responses.Get(status) != nil {
+		return
+	}
+	if len(op.Operation.Responses) == 0 {
+		op.Operation.Responses = make(openapi3.Responses)
+	}
+	op.Operation.Responses[strconv.Itoa(status)] = op.Soda.oaiGenerator.GenerateSharedResponse(
+		validationErrorResponseName,
+		http.StatusText(status),
+		reflect.TypeOf(op.Soda.Options.validationErrorModel),
+		op.Soda.Options.validationErrorContentType,
+	)
+	op.collectErrors()
+}
+
+// addProblemResponse documents the problems rendered by ProblemErrorHandler as the default response,
+// unless the operation declares its default response.
+func (op *Operation) addProblemResponse() {
+	if !op.Soda.Options.problemDetails || op.declaredDefault() != nil {
+		return
+	}
+	if len(op.Operation.Responses) == 0 {
+		op.Operation.Responses = make(openapi3.Responses)
+	}
+	op.Operation.Responses["default"] = op.Soda.oaiGenerator.GenerateSharedResponse(
+		problemResponseName,
+		"Problem Details",
+		reflect.TypeOf(Problem{}),
+		MIMEApplicationProblemJSON,
+	)
+	op.collectErrors()
+}
+
 func BindData(op *Operation) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		c.Locals(keyOperation, op)
 		for _, secHandler := range op.securityHandlers {
 			if err := secHandler(c); err != nil {
 				return err
 			}
 		}
 
-		v := op.Soda.Options.validator
-		if v == nil {
-			return c.Next()
+		if op.Soda.Options.openAPIValidation {
+			if errs := op.validateRequest(c); len(errs) > 0 {
+				return op.Soda.renderError(c, errs)
+			}
 		}
-		if err := op.bindParameter(c, v); err != nil {
-			return err
+
+		if err := op.bindParameter(c, op.validator); err != nil {
+			return op.Soda.renderError(c, err)
 		}
-		if err := op.bindBody(c, v); err != nil {
+		if err := op.bindBody(c, op.validator); err != nil {
+			return op.Soda.renderError(c, err)
+		}
+		if err := c.Next(); err != nil {
 			return err
 		}
-		// TODO: validate response also?
-		return c.Next()
+		if mode := op.Soda.Options.responseValidation; mode != 0 {
+			op.checkResponse(c, mode)
+		}
+		return nil
 	}
 }
 
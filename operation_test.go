@@ -0,0 +1,70 @@
+package soda
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type pageParameters struct {
+	Page int `query:"page" validate:"min=1"`
+}
+
+type nameBody struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func TestBindingWithoutValidation(t *testing.T) {
+	app := New("test", "1.0.0")
+	mustRegister(t, app.Post("/items", func(c *fiber.Ctx) error {
+		params := c.Locals(KeyParameter).(*pageParameters)
+		body := c.Locals(KeyRequestBody).(*nameBody)
+		return c.JSON(map[string]interface{}{"page": params.Page, "name": body.Name})
+	}).SetParameters(pageParameters{}).SetJSONRequestBody(nameBody{}))
+
+	resp := doRequest(t, app, "POST", "/items?page=0", `{}`, fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if want := `{"name":"","page":0}`; resp.status != 200 || resp.body != want {
+		t.Errorf("response = %d %s, want 200 %s", resp.status, resp.body, want)
+	}
+}
+
+func TestValidationSettings(t *testing.T) {
+	strict := validator.New()
+	if err := strict.RegisterValidation("min", func(fl validator.FieldLevel) bool { return false }); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		options   []Option
+		configure func(op *Operation)
+		target    string
+		status    int
+	}{
+		{"disabled by default", nil, nil, "/items?page=0", 200},
+		{"enabled globally", []Option{EnableValidateRequest()}, nil, "/items?page=0", 400},
+		{"valid", []Option{EnableValidateRequest()}, nil, "/items?page=1", 200},
+		{"disabled by the operation", []Option{EnableValidateRequest()},
+			func(op *Operation) { op.SetValidation(false) }, "/items?page=0", 200},
+		{"enabled by the operation", nil,
+			func(op *Operation) { op.SetValidation(true) }, "/items?page=0", 400},
+		{"validator of the operation", []Option{EnableValidateRequest()},
+			func(op *Operation) { op.SetValidator(strict) }, "/items?page=1", 400},
+		{"global validator", []Option{EnableValidateRequest(strict)}, nil, "/items?page=1", 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New("test", "1.0.0", tt.options...)
+			op := app.Get("/items", func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			}).SetParameters(pageParameters{})
+			if tt.configure != nil {
+				tt.configure(op)
+			}
+			mustRegister(t, op)
+			if resp := doRequest(t, app, "GET", tt.target, ""); resp.status != tt.status {
+				t.Errorf("status = %d, want %d: %s", resp.status, tt.status, resp.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package soda
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Problem is an RFC 7807 problem details object, it can be returned by handlers as an error.
+type Problem struct {
+	// Type is a URI reference identifying the problem type, "about:blank" when empty.
+	Type string
+	// Title is a short summary of the problem type.
+	Title string
+	// Status is the HTTP status code.
+	Status int
+	// Detail is an explanation specific to this occurrence of the problem.
+	Detail string
+	// Instance is a URI reference identifying this occurrence of the problem.
+	Instance string
+	// Extensions are additional members of the problem.
+	Extensions map[string]interface{}
+}
+
+// NewProblem creates a problem of status whose title is the status text.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return fmt.Sprintf("%d %s", p.Status, p.Title)
+	}
+	return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+}
+
+// WithExtension adds an additional member to the problem.
+func (p *Problem) WithExtension(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	for k, v := range map[string]string{"type": p.Type, "title": p.Title, "detail": p.Detail, "instance": p.Instance} {
+		if v != "" {
+			members[k] = v
+		}
+	}
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+	return json.Marshal(members)
+}
+
+func (Problem) OAISchema() *openapi3.Schema {
+	schema := openapi3.NewObjectSchema().WithAnyAdditionalProperties()
+	schema.Description = "[RFC 7807](https://datatracker.ietf.org/doc/html/rfc7807) Problem Details"
+	schema.Properties = openapi3.Schemas{
+		"type":     openapi3.NewStringSchema().WithFormat("uri").WithDefault("about:blank").NewRef(),
+		"title":    openapi3.NewStringSchema().NewRef(),
+		"status":   openapi3.NewIntegerSchema().WithMin(100).WithMax(599).NewRef(),
+		"detail":   openapi3.NewStringSchema().NewRef(),
+		"instance": openapi3.NewStringSchema().WithFormat("uri-reference").NewRef(),
+	}
+	return schema
+}
+
+// Render answers the problem as application/problem+json, a problem without status is a 500.
+func (p *Problem) Render(c *fiber.Ctx) error {
+	if p.Status == 0 {
+		problem := *p
+		problem.Status = http.StatusInternalServerError
+		if problem.Title == "" {
+			problem.Title = http.StatusText(problem.Status)
+		}
+		p = &problem
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	c.Status(p.Status)
+	c.Set(fiber.HeaderContentType, MIMEApplicationProblemJSON)
+	return c.Send(body)
+}
+
+// AsProblem converts err to a problem, *fiber.Error keeps its status code and message
+// and ValidationErrors become a 400, Soda.AsProblem uses the status set by WithValidationErrorStatus instead.
+// Other errors become a 500 whose detail is the status text, so that internal messages are not leaked to clients.
+func AsProblem(err error) *Problem {
+	return asProblem(err, http.StatusBadRequest)
+}
+
+// AsProblem converts err to a problem like the AsProblem function,
+// ValidationErrors take the status set by WithValidationErrorStatus.
+func (s *Soda) AsProblem(err error) *Problem {
+	return asProblem(err, s.Options.validationErrorStatus)
+}
+
+func asProblem(err error, validationErrorStatus int) *Problem {
+	var (
+		problem  *Problem
+		errs     ValidationErrors
+		fiberErr *fiber.Error
+	)
+	switch {
+	case errors.As(err, &problem):
+		return problem
+	case errors.As(err, &errs):
+		return NewProblem(validationErrorStatus, "the request is invalid").WithExtension("errors", errs)
+	case errors.As(err, &fiberErr):
+		return NewProblem(fiberErr.Code, fiberErr.Message)
+	default:
+		return NewProblem(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+}
+
+// ProblemErrorHandler is a fiber.ErrorHandler rendering every error as a problem.
+// The ValidationErrors returned by the handlers of an operation take the status set by WithValidationErrorStatus.
+func ProblemErrorHandler(c *fiber.Ctx, err error) error {
+	problem := *AsProblem(err)
+	if op, ok := c.Locals(keyOperation).(*Operation); ok {
+		problem = *op.Soda.AsProblem(err)
+	}
+	if problem.Instance == "" {
+		problem.Instance = c.OriginalURL()
+	}
+	return problem.Render(c)
+}
+
+// RenderValidationProblem is the ValidationErrorRenderer of EnableProblemDetails,
+// the list of errors is the "errors" member of the problem.
+func RenderValidationProblem(c *fiber.Ctx, status int, errs ValidationErrors) error {
+	return ProblemErrorHandler(c, NewProblem(status, "the request is invalid").WithExtension("errors", errs))
+}
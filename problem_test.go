@@ -0,0 +1,145 @@
+package soda
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAsProblem(t *testing.T) {
+	conflict := NewProblem(fiber.StatusConflict, "user is locked")
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"problem", conflict, `{"detail":"user is locked","status":409,"title":"Conflict"}`},
+		{"wrapped problem", fmt.Errorf("get user: %w", conflict), `{"detail":"user is locked","status":409,"title":"Conflict"}`},
+		{"fiber error", fiber.NewError(fiber.StatusNotFound, "no such user"), `{"detail":"no such user","status":404,"title":"Not Found"}`},
+		{"validation errors", ValidationErrors{NewValidationError("query", "page", "is required")},
+			`{"detail":"the request is invalid","errors":[{"field":"page","in":"query","message":"is required"}],"status":400,"title":"Bad Request"}`},
+		{"unknown error", errors.New(`pq: relation "users" does not exist`),
+			`{"detail":"Internal Server Error","status":500,"title":"Internal Server Error"}`},
+	}
+	for _, tt := range tests {
+		if got := toJSON(t, AsProblem(tt.err)); got != tt.want {
+			t.Errorf("%s: AsProblem = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProblemRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		problem *Problem
+		status  int
+		want    string
+	}{
+		{"status", NewProblem(fiber.StatusConflict, "user is locked").WithExtension("user_id", 42), 409,
+			`{"detail":"user is locked","instance":"/users/1","status":409,"title":"Conflict","user_id":42}`},
+		{"without status", &Problem{Type: "https://example.com/locked", Detail: "user is locked"}, 500,
+			`{"detail":"user is locked","instance":"/users/1","status":500,"title":"Internal Server Error","type":"https://example.com/locked"}`},
+		{"without status with title", &Problem{Title: "Locked"}, 500,
+			`{"instance":"/users/1","status":500,"title":"Locked"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New("test", "1.0.0", EnableProblemDetails())
+			mustRegister(t, app.Get("/users/:id", func(c *fiber.Ctx) error {
+				return tt.problem
+			}).SetParameters(groupUser{}))
+			resp := doRequest(t, app, "GET", "/users/1", "")
+			if resp.status != tt.status || resp.body != tt.want {
+				t.Errorf("response = %d %s, want %d %s", resp.status, resp.body, tt.status, tt.want)
+			}
+			if ct := resp.header.Get(fiber.HeaderContentType); ct != MIMEApplicationProblemJSON {
+				t.Errorf("content type = %q", ct)
+			}
+			if tt.problem.Status == 0 && tt.problem.Title == "Internal Server Error" {
+				t.Error("the problem is modified by Render")
+			}
+		})
+	}
+}
+
+func TestProblemDetailsOfFramework(t *testing.T) {
+	app := New("test", "1.0.0", EnableProblemDetails(), EnableValidateRequest())
+	op := mustRegister(t, app.Get("/items", func(c *fiber.Ctx) error {
+		return errors.New("dial tcp 10.0.0.1:5432: connection refused")
+	}).SetParameters(bindingParameters{}))
+	tests := []struct {
+		method, target string
+		status         int
+		want           string
+	}{
+		{"GET", "/items?page_no=1", 400,
+			`{"detail":"the request is invalid","errors":[{"field":"X-Token","in":"header","message":"does not satisfy \"required\""}],"instance":"/items?page_no=1","status":400,"title":"Bad Request"}`},
+		{"GET", "/missing", 404, `{"detail":"Cannot GET /missing","instance":"/missing","status":404,"title":"Not Found"}`},
+	}
+	for _, tt := range tests {
+		resp := doRequest(t, app, tt.method, tt.target, "")
+		if resp.status != tt.status || resp.body != tt.want {
+			t.Errorf("%s %s = %d %s, want %d %s", tt.method, tt.target, resp.status, resp.body, tt.status, tt.want)
+		}
+	}
+	resp := doRequest(t, app, "GET", "/items?page_no=1", "", "X-Token", "t")
+	if resp.status != 500 || strings.Contains(resp.body, "10.0.0.1") {
+		t.Errorf("internal error = %d %s", resp.status, resp.body)
+	}
+	if op.Operation.Responses.Default().Ref != "#/components/responses/"+problemResponseName {
+		t.Error("the problem is not documented as the default response")
+	}
+}
+
+func TestProblemValidationErrorStatus(t *testing.T) {
+	errs := ValidationErrors{NewValidationError("body", "name", "is required")}
+	tests := []struct {
+		name    string
+		options []Option
+		status  int
+	}{
+		{"default status", nil, fiber.StatusBadRequest},
+		{"configured status", []Option{WithValidationErrorStatus(fiber.StatusUnprocessableEntity)}, fiber.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New("test", "1.0.0", append(tt.options, EnableProblemDetails())...)
+			mustRegister(t, app.Post("/users", func(c *fiber.Ctx) error {
+				return errs
+			}))
+			if resp := doRequest(t, app, "POST", "/users", ""); resp.status != tt.status {
+				t.Errorf("status = %d %s, want %d", resp.status, resp.body, tt.status)
+			}
+			if status := app.AsProblem(errs).Status; status != tt.status {
+				t.Errorf("Soda.AsProblem status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestProblemDefaultResponse(t *testing.T) {
+	tests := []struct {
+		name  string
+		model interface{}
+		want  string
+	}{
+		{"placeholder replaced", nil, "#/components/responses/" + problemResponseName},
+		{"declared default kept", validatedUser{}, "#/components/responses/UsersgetDefault"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New("test", "1.0.0", EnableProblemDetails())
+			op := app.Get("/users", func(c *fiber.Ctx) error { return nil })
+			if tt.model != nil {
+				op.AddJSONResponse(0, tt.model)
+			}
+			mustRegister(t, op)
+			if ref := op.Operation.Responses.Default().Ref; ref != tt.want {
+				t.Errorf("default response = %s, want %s", ref, tt.want)
+			}
+		})
+	}
+}
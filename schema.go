@@ -2,8 +2,9 @@ package soda
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"math"
-	"net"
+	"mime/multipart"
 	"net/url"
 	"reflect"
 	"strings"
@@ -14,10 +15,11 @@ import (
 
 var (
 	timeType       = reflect.TypeOf(time.Time{})       // date-time RFC section 7.3.1
-	ipType         = reflect.TypeOf(net.IP{})          // ipv4 and ipv6 RFC section 7.3.4, 7.3.5
 	uriType        = reflect.TypeOf(url.URL{})         // uri RFC section 7.3.6
 	byteSliceType  = reflect.TypeOf([]byte(nil))       // Byte slices will be encoded as base64
 	rawMessageType = reflect.TypeOf(json.RawMessage{}) // Except for json.RawMessage
+	fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+	xmlNameType    = reflect.TypeOf(xml.Name{}) // names the XML element, it is not a property
 )
 
 type getOAISchema interface {
@@ -26,40 +28,110 @@ type getOAISchema interface {
 
 var getOAISchemaFunc = reflect.TypeOf((*getOAISchema)(nil)).Elem()
 
+// SchemaNamer names the schema components of types.
+type SchemaNamer func(t reflect.Type) string
+
+// DefaultSchemaNamer names a type after its package and its name, such as "billingUser",
+// the type arguments of generic types are appended, such as "modelsPageUser" for models.Page[models.User].
+// slices and maps are named after their elements, such as "billingUserList".
+// Types of distinct packages with the same name, such as v1/models.User and v2/models.User, have the same name,
+// which is a registration error.
+func DefaultSchemaNamer(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		switch t.Kind() {
+		case reflect.Ptr:
+			return DefaultSchemaNamer(t.Elem())
+		case reflect.Slice, reflect.Array:
+			return DefaultSchemaNamer(t.Elem()) + "List"
+		case reflect.Map:
+			return DefaultSchemaNamer(t.Elem()) + "Map"
+		}
+		return readableTypeName(t.String())
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	return pkg + readableTypeName(name)
+}
+
+// readableTypeName turns a type name such as "Page[github.com/acme/models.User]" into "PageUser",
+// package qualifiers are dropped and "[]" is written "List".
+func readableTypeName(s string) string {
+	var b strings.Builder
+	for _, token := range strings.FieldsFunc(strings.ReplaceAll(s, "[]", " List "), func(r rune) bool {
+		return strings.ContainsRune("[]*, {};", r)
+	}) {
+		// drop the package qualifier
+		if i := strings.LastIndex(token, "."); i >= 0 {
+			token = token[i+1:]
+		}
+		if token == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(token[:1]) + token[1:])
+	}
+	return b.String()
+}
+
+// getSchemaName names the schema component of rf, two distinct types with the same name are reported.
 func (g *oaiGenerator) getSchemaName(rf reflect.Type) string {
 	for rf.Kind() == reflect.Ptr {
 		rf = rf.Elem()
 	}
-	name := rf.String()
-	return strings.ReplaceAll(name, ".", "")
+	namer := g.schemaNamer
+	if namer == nil {
+		namer = DefaultSchemaNamer
+	}
+	name := namer(rf)
+	if owner, ok := g.schemaTypes[name]; ok && owner != rf {
+		g.addNameError("schema", name, owner, rf)
+		return name
+	}
+	g.schemaTypes[name] = rf
+	return name
 }
 
 func (g *oaiGenerator) getSchemaRef(rf reflect.Type, typ string) *openapi3.SchemaRef {
 	ref, _ := g.genSchema(nil, rf, typ)
-	schemaName := g.getSchemaName(rf)
+	schemaName := g.getComponentName(rf, typ)
 	g.openapi.Components.Schemas[schemaName] = ref
 	return openapi3.NewSchemaRef("#/components/schemas/"+schemaName, ref.Value)
 }
 
-func (g *oaiGenerator) generateCycleSchemaRef(t reflect.Type, schema *openapi3.Schema) *openapi3.SchemaRef {
+// getComponentName names the schema component of rf generated after nameTag.
+func (g *oaiGenerator) getComponentName(rf reflect.Type, nameTag string) string {
+	name := g.getSchemaName(rf)
+	// schemas named after other tags have other properties
+	if nameTag != "json" {
+		name += toCamelCase(nameTag)
+	}
+	return name
+}
+
+func (g *oaiGenerator) generateCycleSchemaRef(t reflect.Type, schema *openapi3.Schema, nameTag string) *openapi3.SchemaRef {
 	switch t.Kind() {
 	case reflect.Ptr:
-		return g.generateCycleSchemaRef(t.Elem(), schema)
+		return g.generateCycleSchemaRef(t.Elem(), schema, nameTag)
 	case reflect.Slice:
-		ref := g.generateCycleSchemaRef(t.Elem(), schema)
-		g.openapi.Components.Schemas[g.getSchemaName(t.Elem())] = openapi3.NewSchemaRef("", ref.Value)
+		ref := g.generateCycleSchemaRef(t.Elem(), schema, nameTag)
 		sliceSchema := openapi3.NewArraySchema()
 		sliceSchema.Items = ref
 		return openapi3.NewSchemaRef("", sliceSchema)
 	case reflect.Map:
-		ref := g.generateCycleSchemaRef(t.Elem(), schema)
-		g.openapi.Components.Schemas[g.getSchemaName(t.Elem())] = openapi3.NewSchemaRef("", ref.Value)
+		ref := g.generateCycleSchemaRef(t.Elem(), schema, nameTag)
 		mapSchema := openapi3.NewObjectSchema()
 		mapSchema.AdditionalProperties = ref
 		return openapi3.NewSchemaRef("", mapSchema)
 	}
 
-	return openapi3.NewSchemaRef("#/components/schemas/"+g.getSchemaName(t), schema)
+	if building, ok := g.building[t]; ok {
+		schema = building
+	}
+	name := g.getComponentName(t, nameTag)
+	g.openapi.Components.Schemas[name] = openapi3.NewSchemaRef("", schema)
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, schema)
 }
 
 func (g *oaiGenerator) genSchema(parents []reflect.Type, t reflect.Type, nameTag string) (*openapi3.SchemaRef, bool) { //nolint
@@ -74,71 +146,83 @@ func (g *oaiGenerator) genSchema(parents []reflect.Type, t reflect.Type, nameTag
 
 	parents = append(parents, t)
 
+	if schema, ok := registeredSchema(t); ok {
+		return schema.NewRef(), false
+	}
+	if t.Kind() != reflect.Interface && reflect.PtrTo(t).Implements(getOAISchemaFunc) {
+		return reflect.New(t).Interface().(getOAISchema).OAISchema().NewRef(), false
+	}
+
 	switch t.Kind() {
 	case reflect.Struct:
-		switch t {
-		case timeType:
-			return openapi3.NewDateTimeSchema().NewRef(), false
-		case uriType:
-			return openapi3.NewStringSchema().WithFormat("uri").NewRef(), false
-		case ipType:
-			return openapi3.NewStringSchema().WithFormat("ipv4").NewRef(), false
-		default:
-			if t.Implements(getOAISchemaFunc) {
-				return reflect.New(t).Interface().(getOAISchema).OAISchema().NewRef(), false
-			}
+		schema := openapi3.NewObjectSchema().WithAnyAdditionalProperties()
+		schema.AdditionalPropertiesAllowed = openapi3.BoolPtr(false)
+		schema.Description = typeDescription(t)
+		g.building[t] = schema
+		defer delete(g.building, t)
 
-			schema := openapi3.NewObjectSchema().WithAnyAdditionalProperties()
-			schema.AdditionalPropertiesAllowed = openapi3.BoolPtr(false)
-
-			for i := 0; i < t.NumField(); i++ {
-				f := t.Field(i)
-				field := newFieldResolver(&f)
-				var fieldSchemaRef *openapi3.SchemaRef
-				if field.ignored {
-					break
+		for _, sf := range structFields(t, nameTag) {
+			f := sf.StructField
+			field := newFieldResolver(&f)
+			if f.Type == xmlNameType {
+				continue
+			}
+			if err := field.check(); err != nil {
+				g.addError(t, &f, err.Error())
+				continue
+			}
+			fieldSchemaRef, cycle := g.genSchema(parents, f.Type, nameTag)
+			if cycle {
+				fieldSchemaRef = g.generateCycleSchemaRef(f.Type, schema, nameTag)
+			}
+			// numbers and booleans with the string option are encoded in JSON strings
+			if nameTag == "json" && field.tagOption(nameTag, "string") {
+				ft := f.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
 				}
-				if field.shouldEmbed() {
-					if ref, cycle := g.genSchema(parents, f.Type, nameTag); cycle {
-						fieldSchemaRef = g.generateCycleSchemaRef(f.Type, schema)
-					} else {
-						fieldSchemaRef = ref
-					}
-					for k, v := range fieldSchemaRef.Value.Properties {
-						schema.Properties[k] = v
-					}
-					continue
+				switch ft.Kind() {
+				case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+					reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+					reflect.Float32, reflect.Float64, reflect.String:
+					fieldSchemaRef = openapi3.NewStringSchema().NewRef()
 				}
-				if ref, cycle := g.genSchema(parents, f.Type, nameTag); cycle {
-					fieldSchemaRef = g.generateCycleSchemaRef(f.Type, schema)
-				} else {
-					fieldSchemaRef = ref
+			}
+			// the oai tag wins over the doc comments of the field and of its type
+			if fieldSchemaRef.Ref == "" {
+				if doc := fieldDescription(t, f.Index); doc != "" {
+					fieldSchemaRef.Value.Description = doc
+				} else if fieldSchemaRef.Value.Description == "" {
+					fieldSchemaRef.Value.Description = typeDescription(indirectType(f.Type))
 				}
+			}
 
+			if fieldSchemaRef.Ref == "" {
 				field.injectOAITags(fieldSchemaRef.Value)
-				if fieldSchemaRef.Value.Nullable {
-					nullSchema := openapi3.NewSchema()
-					nullSchema.Type = "null"
-					fieldSchemaRef.Value = &openapi3.Schema{
-						OneOf: openapi3.SchemaRefs{
-							fieldSchemaRef,
-							nullSchema.NewRef(),
-						},
-					}
-				}
-				schema.Properties[field.name(nameTag)] = fieldSchemaRef
-				if field.required() {
-					schema.Required = append(schema.Required, field.name(nameTag))
+			} else {
+				// referenced schemas are shared and the siblings of references are ignored,
+				// the oai tags of the field only tell if the reference is nullable
+				tagged := *fieldSchemaRef.Value
+				field.injectOAITags(&tagged)
+				if tagged.Nullable && !fieldSchemaRef.Value.Nullable {
+					fieldSchemaRef = openapi3.NewSchemaRef("", &openapi3.Schema{
+						AllOf:    openapi3.SchemaRefs{fieldSchemaRef},
+						Nullable: true,
+					})
 				}
 			}
-
-			return schema.NewRef(), false
+			schema.Properties[sf.name] = fieldSchemaRef
+			if field.required(nameTag) {
+				schema.Required = append(schema.Required, sf.name)
+			}
 		}
+
+		return schema.NewRef(), false
 	case reflect.Map:
 		schema := openapi3.NewObjectSchema()
 		additionalProperties, cycle := g.genSchema(parents, t.Elem(), nameTag)
 		if cycle {
-			additionalProperties = g.generateCycleSchemaRef(t.Elem(), schema)
+			additionalProperties = g.generateCycleSchemaRef(t.Elem(), schema, nameTag)
 		}
 		schema.AdditionalProperties = additionalProperties
 		return schema.NewRef(), false
@@ -156,13 +240,16 @@ func (g *oaiGenerator) genSchema(parents []reflect.Type, t reflect.Type, nameTag
 			schema.MaxItems = &schema.MinItems
 		}
 		if ref, cycle := g.genSchema(parents, t.Elem(), nameTag); cycle {
-			schema.Items = g.generateCycleSchemaRef(t.Elem(), schema)
+			schema.Items = g.generateCycleSchemaRef(t.Elem(), schema, nameTag)
 		} else {
 			schema.Items = ref
 		}
 		return schema.NewRef(), false
 
 	case reflect.Interface:
+		if o, ok := oneOfs[t]; ok {
+			return g.genOneOfSchema(t, o, nameTag), false
+		}
 		return openapi3.NewSchema().WithAnyAdditionalProperties().NewRef(), false
 	case reflect.Int:
 		return openapi3.NewIntegerSchema().NewRef(), false
@@ -198,6 +285,10 @@ func (g *oaiGenerator) genSchema(parents []reflect.Type, t reflect.Type, nameTag
 	case reflect.String:
 		return openapi3.NewStringSchema().NewRef(), false
 	default:
-		panic("unsupported type " + t.String())
+		g.errs = append(g.errs, OpenAPISpecError{
+			Field:  t.String(),
+			Reason: "unsupported type, register its schema with soda.RegisterTypeSchema",
+		})
+		return openapi3.NewSchema().NewRef(), false
 	}
 }
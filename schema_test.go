@@ -0,0 +1,128 @@
+package soda
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+type namedPage[T any] struct {
+	Items []T `json:"items"`
+}
+
+type namedUser struct {
+	Name string `json:"name"`
+}
+
+func TestDefaultSchemaNamer(t *testing.T) {
+	tests := []struct {
+		name  string
+		model interface{}
+		want  string
+	}{
+		{"struct", namedUser{}, "sodaNamedUser"},
+		{"pointer", &namedUser{}, "sodaNamedUser"},
+		{"slice", []namedUser{}, "sodaNamedUserList"},
+		{"map", map[string]namedUser{}, "sodaNamedUserMap"},
+		{"generic", namedPage[namedUser]{}, "sodaNamedPageNamedUser"},
+		{"generic of slice", namedPage[[]namedUser]{}, "sodaNamedPageListNamedUser"},
+		{"builtin", 0, "Int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultSchemaNamer(reflect.TypeOf(tt.model)); got != tt.want {
+				t.Errorf("name = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaNameCollisions(t *testing.T) {
+	// distinct types whose name and package name are the same
+	first := reflect.TypeOf(namedUser{})
+	second := reflect.TypeOf(struct{ namedUser }{})
+	byName := func(t reflect.Type) string { return strings.Split(t.Name(), "[")[0] }
+	constant := func(reflect.Type) string { return "sodaNamedUser" }
+
+	tests := []struct {
+		name    string
+		namer   SchemaNamer
+		types   []reflect.Type
+		wantErr string
+	}{
+		{"same type", nil, []reflect.Type{first, first, reflect.PtrTo(first)}, ""},
+		{"constant namer", constant, []reflect.Type{first, second},
+			`schema component name "sodaNamedUser" is used by both github.com/captain-neo/soda.namedUser and struct { soda.namedUser }`},
+		{"custom namer", byName, []reflect.Type{first, reflect.TypeOf(namedPage[int]{}), reflect.TypeOf(namedPage[string]{})},
+			`is used by both github.com/captain-neo/soda.namedPage[int] and github.com/captain-neo/soda.namedPage[string]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGenerator(&openapi3.Info{}, tt.namer)
+			for _, typ := range tt.types {
+				g.getSchemaName(typ)
+			}
+			errs := g.takeErrors()
+			if tt.wantErr == "" {
+				if len(errs) > 0 {
+					t.Errorf("errs = %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Reason, tt.wantErr) {
+				t.Errorf("errs = %v, want %s", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultSchemaNamerCollision(t *testing.T) {
+	// the first type keeps the name whatever the order, the second one is an error
+	g := newGenerator(&openapi3.Info{}, nil)
+	g.schemaTypes["sodaNamedUser"] = reflect.TypeOf(struct{ namedUser }{})
+	if name := g.getSchemaName(reflect.TypeOf(namedUser{})); name != "sodaNamedUser" {
+		t.Errorf("name = %q", name)
+	}
+	if errs := g.takeErrors(); len(errs) != 1 || errs[0].Field != "sodaNamedUser" {
+		t.Errorf("errs = %v", errs)
+	}
+}
+
+type jsonOptions struct {
+	ID       int64    `json:"id,string"`
+	Ratio    *float64 `json:"ratio,string"`
+	Tags     []int    `json:"tags,string"`
+	Nickname string   `json:"nickname,omitempty"`
+	Password string   `json:"-"`
+	Note     *string  `json:"note"`
+}
+
+func TestJSONTagOptions(t *testing.T) {
+	schema := generateSchema(t, jsonOptions{})
+	tests := []struct {
+		prop string
+		want string
+	}{
+		{"id", `{"type":"string"}`},
+		{"ratio", `{"type":"string"}`},
+		// the string option only applies to scalars
+		{"tags", `{"items":{"type":"integer"},"type":"array"}`},
+		{"nickname", `{"type":"string"}`},
+		{"note", `{"type":"string"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prop, func(t *testing.T) {
+			if got := toJSON(t, schema.Properties[tt.prop]); got != tt.want {
+				t.Errorf("schema = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	if _, ok := schema.Properties["Password"]; ok || len(schema.Properties) != len(tests) {
+		t.Errorf("properties = %v", sortedKeys(schema.Properties))
+	}
+	if want := []string{"id", "tags"}; !reflect.DeepEqual(schema.Required, want) {
+		t.Errorf("required = %v, want %v", schema.Required, want)
+	}
+}
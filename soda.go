@@ -2,6 +2,7 @@ package soda
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"strings"
 	"sync"
@@ -12,12 +13,25 @@ import (
 )
 
 type Options struct {
-	swaggerPath         *string
-	rapiDocPath         *string
-	redocPath           *string
-	openAPISpecJSONPath *string
-	validator           *validator.Validate
-	fiberConfig         []fiber.Config
+	swaggerPath                *string
+	rapiDocPath                *string
+	redocPath                  *string
+	openAPISpecJSONPath        *string
+	openAPISpecYAMLPath        *string
+	swagger2SpecPath           *string
+	validator                  *validator.Validate
+	openAPIValidation          bool
+	responseValidation         ResponseValidationMode
+	validationErrorStatus      int
+	validationErrorRenderer    ValidationErrorRenderer
+	validationErrorModel       interface{}
+	validationErrorContentType string
+	problemDetails             bool
+	codecs                     map[string]Codec
+	schemaNamer                SchemaNamer
+	mock                       bool
+	openAPIVersion             string
+	fiberConfig                []fiber.Config
 }
 type Option func(o *Options)
 
@@ -63,24 +77,115 @@ func EnableValidateRequest(v ...*validator.Validate) Option {
 	}
 }
 
+// EnableOpenAPIRequestValidation validates the parameters and the request body
+// against the operation's OpenAPI specification before the handler runs.
+func EnableOpenAPIRequestValidation() Option {
+	return func(o *Options) {
+		o.openAPIValidation = true
+	}
+}
+
+// EnableResponseValidation checks the status code and the body of every response against the operation's
+// OpenAPI specification, mode decides what happens to responses which do not match.
+// It is meant for development and tests.
+func EnableResponseValidation(mode ResponseValidationMode) Option {
+	return func(o *Options) {
+		o.responseValidation = mode
+	}
+}
+
+// WithValidationErrorStatus sets the status code of invalid requests, 400 by default, 422 is also common.
+func WithValidationErrorStatus(status int) Option {
+	return func(o *Options) {
+		o.validationErrorStatus = status
+	}
+}
+
+// WithValidationErrorRenderer replaces how invalid requests are answered,
+// model describes the rendered body in the OpenAPI specification.
+func WithValidationErrorRenderer(renderer ValidationErrorRenderer, model interface{}) Option {
+	return func(o *Options) {
+		o.validationErrorRenderer = renderer
+		o.validationErrorModel = model
+		o.validationErrorContentType = fiber.MIMEApplicationJSON
+	}
+}
+
+// EnableProblemDetails renders the errors of the framework, such as invalid requests, failed security handlers,
+// 404 and 405, as RFC 7807 application/problem+json, handlers may return a *Problem as well.
+// Every operation documents the problem as its default response.
+// A fiber.Config ErrorHandler given by WithFiberConfig is kept.
+func EnableProblemDetails() Option {
+	return func(o *Options) {
+		o.problemDetails = true
+		o.validationErrorRenderer = RenderValidationProblem
+		o.validationErrorModel = Problem{}
+		o.validationErrorContentType = MIMEApplicationProblemJSON
+	}
+}
+
+// WithCodec registers the codec of a request and response content type,
+// JSON, XML and MessagePack are registered by default.
+func WithCodec(contentType string, codec Codec) Option {
+	return func(o *Options) {
+		o.codecs[contentType] = codec
+	}
+}
+
+// WithSchemaNamer names the schema components with namer instead of DefaultSchemaNamer,
+// two distinct types with the same name are registration errors.
+func WithSchemaNamer(namer SchemaNamer) Option {
+	return func(o *Options) {
+		o.schemaNamer = namer
+	}
+}
+
+// WithMockMode answers the requests to all the operations with their documented examples or with fake data
+// generated from their schemas instead of running their handlers, see Operation.Mock.
+func WithMockMode() Option {
+	return func(o *Options) {
+		o.mock = true
+	}
+}
+
 type Soda struct {
 	specOnce     sync.Once
 	oaiGenerator *oaiGenerator
 	Options      *Options
 	*fiber.App
 	spec []byte
+	errs OpenAPISpecErrors
+
+	specYAMLOnce sync.Once
+	specYAML     []byte
+
+	swagger2Once sync.Once
+	swagger2     []byte
+}
+
+// Build validates the OpenAPI specification and renders it,
+// the problems of every operation registered so far are returned together as OpenAPISpecErrors.
+func (s *Soda) Build() error {
+	errs := append(OpenAPISpecErrors(nil), s.errs...)
+	if err := s.oaiGenerator.openapi.Validate(context.TODO()); err != nil {
+		errs = append(errs, OpenAPISpecError{Position: "openapi", Reason: err.Error()})
+	}
+	spec, err := s.renderSpec()
+	if err != nil {
+		errs = append(errs, OpenAPISpecError{Position: "openapi", Reason: err.Error()})
+	} else {
+		s.spec = spec
+	}
+	return errs.orNil()
 }
 
+// GetOpenAPIJSON returns the rendered OpenAPI specification,
+// it is built on the first call and problems are only logged, call Build to handle them.
 func (s *Soda) GetOpenAPIJSON() []byte {
 	s.specOnce.Do(func() {
-		if err := s.oaiGenerator.openapi.Validate(context.TODO()); err != nil {
-			log.Fatalln(err)
-		}
-		spec, err := s.oaiGenerator.openapi.MarshalJSON()
-		if err != nil {
-			log.Fatalln(err)
+		if err := s.Build(); err != nil {
+			log.Println(err)
 		}
-		s.spec = spec
 	})
 	return s.spec
 }
@@ -90,25 +195,57 @@ func (s *Soda) OpenAPI() *openapi3.T {
 }
 
 func New(title, version string, options ...Option) *Soda {
-	opt := &Options{}
+	opt := &Options{
+		validationErrorStatus:      fiber.StatusBadRequest,
+		validationErrorRenderer:    RenderValidationErrors,
+		validationErrorModel:       ValidationErrors{},
+		validationErrorContentType: fiber.MIMEApplicationJSON,
+		codecs:                     defaultCodecs(),
+		openAPIVersion:             OpenAPIVersion30,
+	}
 	for _, option := range options {
 		option(opt)
 	}
+	var errs OpenAPISpecErrors
+	if opt.openAPIVersion != OpenAPIVersion30 && opt.openAPIVersion != OpenAPIVersion31 {
+		errs = append(errs, OpenAPISpecError{
+			Position: "WithOpenAPIVersion",
+			Reason:   fmt.Sprintf("unsupported version %q, OpenAPI %s is rendered instead", opt.openAPIVersion, OpenAPIVersion30),
+		})
+		opt.openAPIVersion = OpenAPIVersion30
+	}
+	if opt.problemDetails {
+		var config fiber.Config
+		if len(opt.fiberConfig) > 0 {
+			config = opt.fiberConfig[0]
+		}
+		if config.ErrorHandler == nil {
+			config.ErrorHandler = ProblemErrorHandler
+		}
+		opt.fiberConfig = []fiber.Config{config}
+	}
 
 	s := &Soda{
-		oaiGenerator: newGenerator(&openapi3.Info{Title: title, Version: version}),
+		oaiGenerator: newGenerator(&openapi3.Info{Title: title, Version: version}, opt.schemaNamer),
 		App:          fiber.New(opt.fiberConfig...),
 		Options:      opt,
+		errs:         errs,
 	}
 
 	if opt.openAPISpecJSONPath != nil {
-		s.Get(*opt.openAPISpecJSONPath, func(ctx *fiber.Ctx) error {
+		s.addSpecEndpoint(*opt.openAPISpecJSONPath, SpecFormatJSON)
+	}
+	if opt.openAPISpecYAMLPath != nil {
+		s.addSpecEndpoint(*opt.openAPISpecYAMLPath, SpecFormatYAML)
+	}
+	if opt.swagger2SpecPath != nil {
+		s.Get(*opt.swagger2SpecPath, func(ctx *fiber.Ctx) error {
 			ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
-			return ctx.Send(s.GetOpenAPIJSON())
+			return ctx.Send(s.getSwagger2JSON())
 		}).
-			AddTags("Documentation").
-			SetSummary("OpenAPI Specification").
-			SetDescription(`[OpenAPI3](https://swagger.io/specification) OpenAPI Specification File Download`).
+			documentation().
+			SetSummary("Swagger 2.0 Specification").
+			SetDescription(`[Swagger 2.0](https://swagger.io/specification/v2/) Specification File Download, converted from the OpenAPI Specification`).
 			AddResponseWithContentType(200, fiber.MIMEApplicationJSONCharsetUTF8).
 			OK()
 	}
@@ -118,7 +255,7 @@ func New(title, version string, options ...Option) *Soda {
 			ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTML)
 			return ctx.SendString(s.Redoc())
 		}).
-			AddTags("Documentation").
+			documentation().
 			SetSummary("redoc").
 			SetDescription(`[Redoc](https://github.com/Redocly/redoc) OpenAPI Renderer`).
 			AddResponseWithContentType(200, fiber.MIMETextHTMLCharsetUTF8).
@@ -130,7 +267,7 @@ func New(title, version string, options ...Option) *Soda {
 			ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTML)
 			return ctx.SendString(s.Swagger())
 		}).
-			AddTags("Documentation").
+			documentation().
 			SetSummary("swagger").
 			SetDescription(`[Swagger UI](https://swagger.io/tools/swagger-ui/) OpenAPI Renderer`).
 			AddResponseWithContentType(200, fiber.MIMETextHTMLCharsetUTF8).
@@ -141,7 +278,7 @@ func New(title, version string, options ...Option) *Soda {
 		s.Get(*opt.rapiDocPath, func(ctx *fiber.Ctx) error {
 			ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTML)
 			return ctx.SendString(s.RapiDoc())
-		}).AddTags("Documentation").
+		}).documentation().
 			SetSummary("rapidoc").
 			SetDescription(`[RapiDoc](https://github.com/mrin9/RapiDoc) OpenAPI Renderer`).
 			AddResponseWithContentType(200, fiber.MIMETextHTMLCharsetUTF8).
@@ -150,9 +287,18 @@ func New(title, version string, options ...Option) *Soda {
 	return s
 }
 
+// renderError renders ValidationErrors with the configured renderer, other errors are returned as is.
+func (s *Soda) renderError(c *fiber.Ctx, err error) error {
+	if errs, ok := err.(ValidationErrors); ok {
+		return s.Options.validationErrorRenderer(c, s.Options.validationErrorStatus, errs)
+	}
+	return err
+}
+
 func (s *Soda) newOperation(path, method string, handlers ...fiber.Handler) *Operation {
 	operation := openapi3.NewOperation()
-	operation.AddResponse(0, openapi3.NewResponse().WithDescription("OK"))
+	placeholder := openapi3.NewResponse().WithDescription("OK")
+	operation.AddResponse(0, placeholder)
 	op := &Operation{
 		Operation:    operation,
 		Path:         path,
@@ -161,6 +307,7 @@ func (s *Soda) newOperation(path, method string, handlers ...fiber.Handler) *Ope
 		TRequestBody: nil,
 		Soda:         s,
 		handlers:     handlers,
+		placeholder:  placeholder,
 	}
 	return op
 }
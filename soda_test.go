@@ -0,0 +1,73 @@
+package soda
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// testResponse is the response of a request sent by doRequest.
+type testResponse struct {
+	status int
+	header http.Header
+	body   string
+}
+
+// doRequest sends a request to app, headers are given as name and value pairs.
+func doRequest(t *testing.T, app *Soda, method, target, body string, headers ...string) testResponse {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testResponse{status: resp.StatusCode, header: resp.Header, body: string(data)}
+}
+
+// mustRegister registers op and fails the test if it is invalid.
+func mustRegister(t *testing.T, op *Operation) *Operation {
+	t.Helper()
+	if err := op.Register(); err != nil {
+		t.Fatal(err)
+	}
+	return op
+}
+
+// generateSchema generates the JSON schema of model with a new generator.
+func generateSchema(t *testing.T, model interface{}) *openapi3.Schema {
+	t.Helper()
+	g := newGenerator(&openapi3.Info{}, nil)
+	ref := g.getSchemaRef(reflect.TypeOf(model), "json")
+	if errs := g.takeErrors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	return ref.Value
+}
+
+// toJSON marshals v, it fails the test if v can not be marshaled.
+func toJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,93 @@
+package soda
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
+	"github.com/invopop/yaml"
+)
+
+// SpecFormat is a format in which the OpenAPI specification is written.
+type SpecFormat string
+
+const (
+	SpecFormatJSON SpecFormat = "json"
+	SpecFormatYAML SpecFormat = "yaml"
+)
+
+// yamlContentTypes are the content types requesting the specification in YAML, the first one is answered by default.
+var yamlContentTypes = []string{MIMEApplicationYAML, "application/x-yaml", "text/yaml"}
+
+// WithOpenAPISpecYAML serves the OpenAPI specification in YAML at path,
+// or in JSON to the requests preferring it by their Accept header.
+func WithOpenAPISpecYAML(path string) Option {
+	return func(o *Options) {
+		o.openAPISpecYAMLPath = &path
+	}
+}
+
+// GetOpenAPIYAML returns the rendered OpenAPI specification in YAML,
+// it is built on the first call and problems are only logged, call WriteSpec to handle them.
+func (s *Soda) GetOpenAPIYAML() []byte {
+	s.specYAMLOnce.Do(func() {
+		spec, err := yaml.JSONToYAML(s.GetOpenAPIJSON())
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		s.specYAML = spec
+	})
+	return s.specYAML
+}
+
+// WriteSpec builds the OpenAPI specification and writes it to w in format,
+// so that it can be exported, by a build pipeline for instance, without serving it.
+// The problems of the specification are returned as by Build, and nothing is written.
+func (s *Soda) WriteSpec(w io.Writer, format SpecFormat) error {
+	if format != SpecFormatJSON && format != SpecFormatYAML {
+		return fmt.Errorf("soda: unknown specification format %q", format)
+	}
+	if err := s.Build(); err != nil {
+		return err
+	}
+	spec := s.spec
+	if format == SpecFormatYAML {
+		var err error
+		if spec, err = yaml.JSONToYAML(spec); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(spec)
+	return err
+}
+
+// addSpecEndpoint serves the OpenAPI specification at path, in format unless the Accept header prefers the other one.
+func (s *Soda) addSpecEndpoint(path string, format SpecFormat) {
+	offers := append([]string{fiber.MIMEApplicationJSON}, yamlContentTypes...)
+	if format == SpecFormatYAML {
+		offers = append(append([]string(nil), yamlContentTypes...), fiber.MIMEApplicationJSON)
+	}
+	op := s.Get(path, func(ctx *fiber.Ctx) error {
+		switch ct := ctx.Accepts(offers...); ct {
+		case "":
+			return fiber.ErrNotAcceptable
+		case fiber.MIMEApplicationJSON:
+			ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+			return ctx.Send(s.GetOpenAPIJSON())
+		default:
+			ctx.Set(fiber.HeaderContentType, ct+"; charset=utf-8")
+			return ctx.Send(s.GetOpenAPIYAML())
+		}
+	}).
+		documentation().
+		SetSummary("OpenAPI Specification").
+		SetDescription(`[OpenAPI3](https://swagger.io/specification) OpenAPI Specification File Download, in JSON or YAML`)
+	// every content type the endpoint answers is documented
+	content := openapi3.NewContentWithSchema(openapi3.NewSchema(), offers)
+	op.Operation.AddResponse(http.StatusOK, openapi3.NewResponse().WithContent(content).WithDescription(http.StatusText(http.StatusOK)))
+	op.OK()
+}
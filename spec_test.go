@@ -0,0 +1,89 @@
+package soda
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSpecEndpoint(t *testing.T) {
+	tests := []struct {
+		name        string
+		option      Option
+		path        string
+		accept      string
+		status      int
+		contentType string
+		want        string
+	}{
+		{"json", WithOpenAPISpec("/openapi.json"), "/openapi.json", "", 200, fiber.MIMEApplicationJSONCharsetUTF8, `"openapi":"3.0.3"`},
+		{"json prefers yaml", WithOpenAPISpec("/openapi.json"), "/openapi.json", "text/yaml", 200, "text/yaml; charset=utf-8", "openapi: 3.0.3"},
+		{"yaml", WithOpenAPISpecYAML("/openapi.yaml"), "/openapi.yaml", "", 200, MIMEApplicationYAML + "; charset=utf-8", "openapi: 3.0.3"},
+		{"x-yaml", WithOpenAPISpecYAML("/openapi.yaml"), "/openapi.yaml", "application/x-yaml", 200, "application/x-yaml; charset=utf-8", "openapi: 3.0.3"},
+		{"yaml prefers json", WithOpenAPISpecYAML("/openapi.yaml"), "/openapi.yaml", fiber.MIMEApplicationJSON, 200,
+			fiber.MIMEApplicationJSONCharsetUTF8, `"openapi":"3.0.3"`},
+		{"not acceptable", WithOpenAPISpec("/openapi.json"), "/openapi.json", fiber.MIMETextHTML, 406, fiber.MIMETextPlainCharsetUTF8, "Not Acceptable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// responses which are not documented get a Warning header
+			app := New("test", "1.0.0", tt.option, EnableResponseValidation(ResponseValidationWarn))
+			var headers []string
+			if tt.accept != "" {
+				headers = []string{fiber.HeaderAccept, tt.accept}
+			}
+			resp := doRequest(t, app, "GET", tt.path, "", headers...)
+			if resp.status != tt.status || !strings.Contains(resp.body, tt.want) {
+				t.Errorf("response = %d %s, want %d %s", resp.status, resp.body, tt.status, tt.want)
+			}
+			if ct := resp.header.Get(fiber.HeaderContentType); ct != tt.contentType {
+				t.Errorf("content type = %q, want %q", ct, tt.contentType)
+			}
+			if tt.status == 200 {
+				if warning := resp.header.Get(fiber.HeaderWarning); warning != "" {
+					t.Errorf("warning = %s", warning)
+				}
+			}
+		})
+	}
+}
+
+func TestSpecEndpointContent(t *testing.T) {
+	app := New("test", "1.0.0", WithOpenAPISpecYAML("/openapi.yaml"))
+	content := app.OpenAPI().Paths["/openapi.yaml"].Get.Responses.Get(200).Value.Content
+	want := []string{fiber.MIMEApplicationJSON, "application/x-yaml", MIMEApplicationYAML, "text/yaml"}
+	if got := sortedKeys(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("content = %v, want %v", got, want)
+	}
+}
+
+func TestWriteSpec(t *testing.T) {
+	tests := []struct {
+		format  SpecFormat
+		want    string
+		wantErr string
+	}{
+		{SpecFormatJSON, `"openapi":"3.0.3"`, ""},
+		{SpecFormatYAML, "openapi: 3.0.3", ""},
+		{"toml", "", `unknown specification format "toml"`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			app := New("test", "1.0.0")
+			var buf bytes.Buffer
+			err := app.WriteSpec(&buf, tt.format)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || buf.Len() > 0 {
+					t.Errorf("err = %v, output = %s", err, buf.String())
+				}
+				return
+			}
+			if err != nil || !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("err = %v, output = %s", err, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,597 @@
+package soda
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Swagger2Error reports a construct of the OpenAPI specification which Swagger 2.0 can not represent,
+// it is left out of the converted specification.
+type Swagger2Error struct {
+	Position string
+	Reason   string
+}
+
+func (e Swagger2Error) Error() string {
+	return fmt.Sprintf("swagger 2.0 conversion: %s: %s", e.Position, e.Reason)
+}
+
+// Swagger2Errors aggregates the constructs left out of the Swagger 2.0 specification.
+type Swagger2Errors []Swagger2Error
+
+func (es Swagger2Errors) Error() string {
+	msg := make([]string, 0, len(es))
+	for _, e := range es {
+		msg = append(msg, e.Error())
+	}
+	return strings.Join(msg, "\n")
+}
+
+// WithSwagger2Spec serves the specification converted into Swagger 2.0 at path, see Soda.Swagger2JSON.
+func WithSwagger2Spec(path string) Option {
+	return func(o *Options) {
+		o.swagger2SpecPath = &path
+	}
+}
+
+// Swagger2JSON converts the OpenAPI specification into Swagger 2.0, for the tools which only read it.
+// The constructs which Swagger 2.0 can not represent, such as oneOf schemas, cookie parameters
+// or distinct schemas for the content types of a body, are left out and reported as Swagger2Errors,
+// which are returned along with the converted specification.
+func (s *Soda) Swagger2JSON() ([]byte, error) {
+	data, err := s.oaiGenerator.openapi.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	// the conversion changes the document, it converts a copy
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return nil, err
+	}
+	c := &swagger2Converter{
+		doc:       doc,
+		visited:   make(map[*openapi3.Schema]bool),
+		binaries:  make(map[*openapi3.Schema]bool),
+		consumes:  make(map[*openapi3.Operation][]string),
+		responses: make(map[*openapi3.Response]*swagger2Response),
+	}
+	c.prepare()
+	doc2, err := openapi2conv.FromV3(doc)
+	if err != nil {
+		return nil, err
+	}
+	c.finish(doc2)
+	spec, err := doc2.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	if len(c.errs) > 0 {
+		return spec, c.errs
+	}
+	return spec, nil
+}
+
+// getSwagger2JSON returns the Swagger 2.0 specification, it is converted on the first call and problems are only logged.
+func (s *Soda) getSwagger2JSON() []byte {
+	s.swagger2Once.Do(func() {
+		spec, err := s.Swagger2JSON()
+		if err != nil {
+			log.Println(err)
+		}
+		s.swagger2 = spec
+	})
+	return s.swagger2
+}
+
+// swagger2Response is what a response keeps in Swagger 2.0: the content types sharing the schema of the first one,
+// and an example of each of them.
+type swagger2Response struct {
+	produces []string
+	examples map[string]interface{}
+}
+
+// swagger2Converter prepares a copy of the OpenAPI specification for openapi2conv.FromV3,
+// which drops or mistranslates what Swagger 2.0 can not represent, and completes its result.
+type swagger2Converter struct {
+	doc  *openapi3.T
+	errs Swagger2Errors
+
+	visited map[*openapi3.Schema]bool
+	// binaries are the binary strings, whose format is hidden from FromV3 which only expects them in forms
+	binaries  map[*openapi3.Schema]bool
+	consumes  map[*openapi3.Operation][]string
+	responses map[*openapi3.Response]*swagger2Response
+}
+
+func (c *swagger2Converter) report(position, reason string) {
+	c.errs = append(c.errs, Swagger2Error{Position: position, Reason: reason})
+}
+
+// prepare reports and removes the constructs which Swagger 2.0 can not represent, and inlines the request bodies.
+func (c *swagger2Converter) prepare() {
+	doc := c.doc
+	if _, ok := doc.Extensions[webhooksExtension]; ok {
+		c.report("webhooks", "webhooks cannot be represented")
+		delete(doc.Extensions, webhooksExtension)
+	}
+	if len(doc.Servers) > 1 {
+		c.report("servers", "only the host and base path of the first server are kept")
+	}
+	for _, server := range doc.Servers {
+		if len(server.Variables) > 0 {
+			c.report("server "+server.URL, "server variables cannot be represented")
+		}
+	}
+
+	components := &doc.Components
+	for _, name := range sortedKeys(components.SecuritySchemes) {
+		c.securityScheme(name, components.SecuritySchemes[name].Value)
+	}
+	for _, name := range sortedKeys(components.Schemas) {
+		c.schema("schema "+name, components.Schemas[name])
+	}
+	for _, name := range sortedKeys(components.Parameters) {
+		if !c.parameter("parameter "+name, components.Parameters[name]) {
+			delete(components.Parameters, name)
+		}
+	}
+	for _, name := range sortedKeys(components.Headers) {
+		c.schema("header "+name, components.Headers[name].Value.Schema)
+	}
+	for _, name := range sortedKeys(components.Responses) {
+		c.response("response "+name, components.Responses[name].Value)
+	}
+	if len(components.Examples) > 0 || len(components.Links) > 0 || len(components.Callbacks) > 0 {
+		c.report("components", "examples, links and callbacks components cannot be represented")
+	}
+	// operations declare their bodies as parameters, the request bodies are inlined into them
+	components.RequestBodies = nil
+
+	for _, path := range sortedKeys(doc.Paths) {
+		pathItem := doc.Paths[path]
+		pathItem.Parameters = c.parameters(path, pathItem.Parameters)
+		operations := pathItem.Operations()
+		for _, method := range sortedKeys(operations) {
+			c.operation(method+" "+path, operations[method])
+		}
+	}
+}
+
+func (c *swagger2Converter) securityScheme(name string, scheme *openapi3.SecurityScheme) {
+	position := "security scheme " + name
+	switch scheme.Type {
+	case "http":
+		// bearer tokens are documented as an Authorization header, the way Swagger 2.0 documents them
+		if scheme.Scheme != "basic" && !strings.EqualFold(scheme.Scheme, "bearer") {
+			c.report(position, fmt.Sprintf("the %s scheme is documented as an Authorization header", scheme.Scheme))
+		}
+	case "apiKey":
+		if scheme.In == openapi3.ParameterInCookie {
+			c.report(position, "API keys in cookies cannot be represented")
+			c.removeSecurityScheme(name)
+		}
+	case "oauth2":
+		if flows := scheme.Flows; flows != nil {
+			n := 0
+			for _, flow := range []*openapi3.OAuthFlow{flows.Implicit, flows.AuthorizationCode, flows.Password, flows.ClientCredentials} {
+				if flow != nil {
+					n++
+				}
+			}
+			if n > 1 {
+				c.report(position, "only the first OAuth2 flow is kept")
+			}
+		}
+	default:
+		c.report(position, fmt.Sprintf("%s security schemes cannot be represented", scheme.Type))
+		c.removeSecurityScheme(name)
+	}
+}
+
+// removeSecurityScheme removes the security scheme name and the security requirements using it.
+func (c *swagger2Converter) removeSecurityScheme(name string) {
+	delete(c.doc.Components.SecuritySchemes, name)
+	remove := func(requirements openapi3.SecurityRequirements) openapi3.SecurityRequirements {
+		kept := requirements[:0]
+		for _, requirement := range requirements {
+			if _, ok := requirement[name]; ok && len(requirement) == 1 {
+				continue
+			}
+			delete(requirement, name)
+			kept = append(kept, requirement)
+		}
+		return kept
+	}
+	c.doc.Security = remove(c.doc.Security)
+	for _, pathItem := range c.doc.Paths {
+		for _, operation := range pathItem.Operations() {
+			if operation.Security != nil {
+				requirements := remove(*operation.Security)
+				operation.Security = &requirements
+			}
+		}
+	}
+}
+
+func (c *swagger2Converter) operation(position string, operation *openapi3.Operation) {
+	if len(operation.Callbacks) > 0 {
+		c.report(position, "callbacks cannot be represented")
+		operation.Callbacks = nil
+	}
+	if operation.Servers != nil {
+		c.report(position, "the servers of operations cannot be represented")
+		operation.Servers = nil
+	}
+	operation.Parameters = c.parameters(position, operation.Parameters)
+	if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+		c.requestBody(position, operation)
+	}
+	for _, code := range sortedKeys(operation.Responses) {
+		if response := operation.Responses[code]; response.Ref == "" {
+			c.response(position+" response "+code, response.Value)
+		}
+	}
+}
+
+// parameters returns the parameters which Swagger 2.0 can represent.
+func (c *swagger2Converter) parameters(position string, parameters openapi3.Parameters) openapi3.Parameters {
+	kept := parameters[:0]
+	for _, parameter := range parameters {
+		if c.parameter(position, parameter) {
+			kept = append(kept, parameter)
+		}
+	}
+	return kept
+}
+
+// parameter reports the parts of parameter which Swagger 2.0 can not represent, ok tells if it is kept.
+func (c *swagger2Converter) parameter(position string, ref *openapi3.ParameterRef) (ok bool) {
+	if ref.Ref != "" || ref.Value == nil {
+		return true
+	}
+	parameter := ref.Value
+	position += fmt.Sprintf(" %s parameter %s", parameter.In, parameter.Name)
+	switch {
+	case parameter.In == openapi3.ParameterInCookie:
+		c.report(position, "cookie parameters cannot be represented")
+		return false
+	case parameter.Schema == nil || parameter.Schema.Value == nil:
+		c.report(position, "parameters described by their content cannot be represented")
+		return false
+	}
+	schema := parameter.Schema.Value
+	if schema.Type == openapi3.TypeArray && schema.Items != nil && schema.Items.Value != nil {
+		schema = schema.Items.Value
+	}
+	if schema.Type == openapi3.TypeObject || schema.Type == openapi3.TypeArray || len(schema.Properties) > 0 {
+		c.report(position, "object and nested array parameters cannot be represented")
+		return false
+	}
+	if parameter.Deprecated {
+		c.report(position, "deprecated parameters cannot be represented")
+	}
+	if parameter.Example != nil || len(parameter.Examples) > 0 || parameter.Schema.Value.Example != nil {
+		c.report(position, "examples of parameters cannot be represented")
+	}
+	c.schema(position, parameter.Schema)
+	return true
+}
+
+// requestBody inlines the request body of operation with a single content type, the first one,
+// the others are only kept as consumed content types if they share its schema.
+func (c *swagger2Converter) requestBody(position string, operation *openapi3.Operation) {
+	body := *operation.RequestBody.Value
+	position += " request body"
+	contentTypes := sortedKeys(body.Content)
+	if len(contentTypes) == 0 {
+		return
+	}
+	sortOffers(contentTypes)
+	first := contentTypes[0]
+	media := body.Content[first]
+	consumes := []string{first}
+	for _, ct := range contentTypes[1:] {
+		if isFormContentType(ct) != isFormContentType(first) || !sameSchema(body.Content[ct].Schema, media.Schema) {
+			c.report(position+" "+ct, "an operation has a single body schema in Swagger 2.0, the one of "+first+" is kept")
+			continue
+		}
+		consumes = append(consumes, ct)
+	}
+	if media.Example != nil || len(media.Examples) > 0 {
+		c.report(position, "examples of request bodies cannot be represented")
+	}
+	c.schema(position, media.Schema)
+	if isFormContentType(first) && media.Schema != nil && media.Schema.Value != nil {
+		form := *media
+		form.Schema = c.formSchema(position, media.Schema)
+		media = &form
+	}
+	body.Content = openapi3.Content{first: media}
+	operation.RequestBody = &openapi3.RequestBodyRef{Value: &body}
+	c.consumes[operation] = consumes
+}
+
+// formSchema returns a copy of the schema of a form whose fields are inlined, since FromV3 turns the references
+// of fields into references to parameters. The fields which Swagger 2.0 can not represent are reported and left out.
+func (c *swagger2Converter) formSchema(position string, ref *openapi3.SchemaRef) *openapi3.SchemaRef {
+	schema := *ref.Value
+	schema.Properties = make(openapi3.Schemas, len(ref.Value.Properties))
+	for _, name := range sortedKeys(ref.Value.Properties) {
+		prop := ref.Value.Properties[name].Value
+		if prop == nil {
+			continue
+		}
+		item := prop
+		if item.Type == openapi3.TypeArray && item.Items != nil && item.Items.Value != nil {
+			item = item.Items.Value
+		}
+		switch {
+		case item.Type == openapi3.TypeObject || item.Type == openapi3.TypeArray || len(item.Properties) > 0:
+			c.report(position+" field "+name, "object and nested array form fields cannot be represented")
+			continue
+		case len(item.OneOf) > 0 || len(item.AnyOf) > 0 || len(item.AllOf) > 0:
+			c.report(position+" field "+name, "oneOf, anyOf and allOf form fields cannot be represented")
+			continue
+		}
+		if prop.Items != nil && prop.Items.Ref != "" {
+			inlined := *prop
+			inlined.Items = openapi3.NewSchemaRef("", prop.Items.Value)
+			if c.binaries[prop] {
+				c.binaries[&inlined] = true
+			}
+			prop = &inlined
+		}
+		schema.Properties[name] = openapi3.NewSchemaRef("", prop)
+	}
+	return openapi3.NewSchemaRef("", &schema)
+}
+
+// response records the content types and the examples kept by response, which has a single schema in Swagger 2.0.
+func (c *swagger2Converter) response(position string, response *openapi3.Response) {
+	if len(response.Links) > 0 {
+		c.report(position, "links cannot be represented")
+	}
+	for _, name := range sortedKeys(response.Headers) {
+		if header := response.Headers[name]; header.Value != nil {
+			c.schema(position+" header "+name, header.Value.Schema)
+		}
+	}
+	contentTypes := sortedKeys(response.Content)
+	if len(contentTypes) == 0 {
+		return
+	}
+	sortOffers(contentTypes)
+	first := contentTypes[0]
+	r := &swagger2Response{examples: make(map[string]interface{})}
+	for _, ct := range contentTypes {
+		media := response.Content[ct]
+		if ct != first && !sameSchema(media.Schema, response.Content[first].Schema) {
+			c.report(position+" "+ct, "a response has a single schema in Swagger 2.0, the one of "+first+" is kept")
+			continue
+		}
+		r.produces = append(r.produces, ct)
+		if media.Example != nil {
+			r.examples[ct] = media.Example
+		} else if names := sortedKeys(media.Examples); len(names) > 0 {
+			if len(names) > 1 {
+				c.report(position+" "+ct, "a response has a single example per content type in Swagger 2.0, the one named "+names[0]+" is kept")
+			}
+			if example := media.Examples[names[0]].Value; example != nil {
+				r.examples[ct] = example.Value
+			}
+		}
+	}
+	c.schema(position, response.Content[first].Schema)
+	c.responses[response] = r
+}
+
+// schema reports and removes the keywords of the schema at position which Swagger 2.0 can not represent,
+// nullable schemas are marked by the common x-nullable extension.
+func (c *swagger2Converter) schema(position string, ref *openapi3.SchemaRef) {
+	// referenced schemas are components, which are converted on their own
+	if ref == nil || ref.Ref != "" || ref.Value == nil || c.visited[ref.Value] {
+		return
+	}
+	schema := ref.Value
+	c.visited[schema] = true
+	if len(schema.OneOf) > 0 || len(schema.AnyOf) > 0 || schema.Not != nil || schema.Discriminator != nil {
+		c.report(position, "oneOf, anyOf, not and discriminator cannot be represented")
+		schema.OneOf, schema.AnyOf, schema.Not, schema.Discriminator = nil, nil, nil, nil
+	}
+	if schema.WriteOnly {
+		c.report(position, "writeOnly cannot be represented")
+		schema.WriteOnly = false
+	}
+	if schema.Deprecated {
+		c.report(position, "deprecated schemas cannot be represented")
+		schema.Deprecated = false
+	}
+	if schema.Nullable {
+		if schema.Extensions == nil {
+			schema.Extensions = make(map[string]interface{})
+		}
+		schema.Extensions["x-nullable"] = true
+		schema.Nullable = false
+	}
+	if schema.Type == openapi3.TypeString && schema.Format == "binary" {
+		c.binaries[schema] = true
+		schema.Format = ""
+	}
+
+	c.schema(position+"[]", schema.Items)
+	c.schema(position+"{}", schema.AdditionalProperties)
+	for _, s := range schema.AllOf {
+		c.schema(position, s)
+	}
+	for _, name := range sortedKeys(schema.Properties) {
+		c.schema(position+"."+name, schema.Properties[name])
+	}
+}
+
+// finish completes the conversion of FromV3 with the content types, the examples,
+// the collection formats of array parameters and the file uploads.
+func (c *swagger2Converter) finish(doc2 *openapi2.T) {
+	for schema := range c.binaries {
+		schema.Format = "binary"
+	}
+	// FromV3 merges the content types of all the request bodies into the global ones
+	doc2.Consumes = nil
+	for name, ref := range c.doc.Components.Responses {
+		c.finishResponse(ref.Value, doc2.Responses[name])
+	}
+	for name, ref := range c.doc.Components.Parameters {
+		finishParameter(ref.Value, doc2.Parameters[name])
+	}
+
+	for path, pathItem := range c.doc.Paths {
+		pathItem2 := doc2.Paths[path]
+		finishParameters(pathItem.Parameters, pathItem2.Parameters)
+		for method, operation := range pathItem.Operations() {
+			operation2 := pathItem2.GetOperation(method)
+			operation2.ExternalDocs = operation.ExternalDocs
+			operation2.Consumes = c.consumes[operation]
+			operation2.Produces = nil
+			for _, code := range sortedKeys(operation.Responses) {
+				ref := operation.Responses[code]
+				if ref.Ref == "" {
+					c.finishResponse(ref.Value, operation2.Responses[code])
+				}
+				if r := c.responses[ref.Value]; r != nil {
+					operation2.Produces = appendMissing(operation2.Produces, r.produces...)
+				}
+			}
+			sortOffers(operation2.Produces)
+			finishParameters(operation.Parameters, operation2.Parameters)
+			if operation.RequestBody != nil {
+				for _, media := range operation.RequestBody.Value.Content {
+					c.finishFormData(media.Schema, operation2.Parameters)
+				}
+			}
+		}
+	}
+}
+
+func (c *swagger2Converter) finishResponse(response *openapi3.Response, response2 *openapi2.Response) {
+	r := c.responses[response]
+	if r == nil || response2 == nil {
+		return
+	}
+	// FromV3 only converts the JSON schemas
+	if first := r.produces[0]; first != fiber.MIMEApplicationJSON {
+		response2.Schema, _ = openapi2conv.FromV3SchemaRef(response.Content[first].Schema, &c.doc.Components)
+	}
+	if len(r.examples) > 0 {
+		response2.Examples = r.examples
+	}
+}
+
+// finishFormData documents the file uploads and the required fields of forms, which FromV3 misses.
+func (c *swagger2Converter) finishFormData(schema *openapi3.SchemaRef, parameters2 openapi2.Parameters) {
+	if schema == nil || schema.Value == nil {
+		return
+	}
+	for _, parameter2 := range parameters2 {
+		prop, ok := schema.Value.Properties[parameter2.Name]
+		if parameter2.In != "formData" || !ok {
+			continue
+		}
+		if c.binaries[prop.Value] {
+			parameter2.Type = "file"
+			parameter2.Format = ""
+		}
+		parameter2.Required = false
+		for _, name := range schema.Value.Required {
+			if name == parameter2.Name {
+				parameter2.Required = true
+			}
+		}
+		if parameter2.Type == openapi3.TypeArray {
+			parameter2.CollectionFormat = "multi"
+		}
+	}
+}
+
+func finishParameters(parameters openapi3.Parameters, parameters2 openapi2.Parameters) {
+	for _, ref := range parameters {
+		if ref.Value == nil {
+			continue
+		}
+		for _, parameter2 := range parameters2 {
+			if parameter2.Name == ref.Value.Name && parameter2.In == ref.Value.In {
+				finishParameter(ref.Value, parameter2)
+			}
+		}
+	}
+}
+
+// finishParameter sets the collection format of array parameters from their style.
+func finishParameter(parameter *openapi3.Parameter, parameter2 *openapi2.Parameter) {
+	if parameter == nil || parameter2 == nil || parameter2.Type != openapi3.TypeArray {
+		return
+	}
+	sm, err := parameter.SerializationMethod()
+	if err != nil {
+		return
+	}
+	switch sm.Style {
+	case openapi3.SerializationForm:
+		if sm.Explode {
+			parameter2.CollectionFormat = "multi"
+		} else {
+			parameter2.CollectionFormat = "csv"
+		}
+	case openapi3.SerializationSimple:
+		parameter2.CollectionFormat = "csv"
+	case openapi3.SerializationSpaceDelimited:
+		parameter2.CollectionFormat = "ssv"
+	case openapi3.SerializationPipeDelimited:
+		parameter2.CollectionFormat = "pipes"
+	}
+}
+
+func isFormContentType(ct string) bool {
+	return ct == fiber.MIMEApplicationForm || ct == fiber.MIMEMultipartForm
+}
+
+// sameSchema tells if a and b describe the same values, they are the same reference or the same inline schema.
+func sameSchema(a, b *openapi3.SchemaRef) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Ref != "" || b.Ref != "" {
+		return a.Ref == b.Ref
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+func appendMissing(values []string, more ...string) []string {
+outer:
+	for _, v := range more {
+		for _, value := range values {
+			if v == value {
+				continue outer
+			}
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
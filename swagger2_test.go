@@ -0,0 +1,129 @@
+package soda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/gofiber/fiber/v2"
+)
+
+type swagger2Node struct {
+	Name     string         `form:"name"`
+	Children []swagger2Node `form:"children"`
+}
+
+type swagger2Form struct {
+	Title  string                `form:"title"`
+	Tags   []string              `form:"tags"`
+	Avatar *multipart.FileHeader `form:"avatar"`
+	Parent *swagger2Form         `form:"parent"`
+	Tree   swagger2Node          `form:"tree"`
+}
+
+func TestSwagger2FormData(t *testing.T) {
+	app := New("test", "1.0.0")
+	mustRegister(t, app.Post("/upload", func(c *fiber.Ctx) error {
+		return nil
+	}).SetMultipartRequestBody(swagger2Form{}))
+	spec, err := app.Swagger2JSON()
+
+	var left Swagger2Errors
+	if !errors.As(err, &left) {
+		t.Fatalf("err = %v, want Swagger2Errors", err)
+	}
+	var positions []string
+	for _, e := range left {
+		positions = append(positions, e.Position)
+	}
+	wantPositions := []string{"POST /upload request body field parent", "POST /upload request body field tree"}
+	if !reflect.DeepEqual(positions, wantPositions) {
+		t.Errorf("positions = %v, want %v", positions, wantPositions)
+	}
+
+	var doc2 openapi2.T
+	if err := json.Unmarshal(spec, &doc2); err != nil {
+		t.Fatal(err)
+	}
+	assertSwagger2Refs(t, &doc2, spec)
+
+	tests := []struct {
+		name             string
+		typ              string
+		required         bool
+		collectionFormat string
+	}{
+		{"avatar", "file", false, ""},
+		{"tags", "array", true, "multi"},
+		{"title", "string", true, ""},
+	}
+	parameters := doc2.Paths["/upload"].Post.Parameters
+	if len(parameters) != len(tests) {
+		t.Fatalf("parameters = %s, want %d", toJSON(t, parameters), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parameters[i]
+			if p.Name != tt.name || p.In != "formData" || p.Type != tt.typ || p.Required != tt.required ||
+				p.CollectionFormat != tt.collectionFormat {
+				t.Errorf("parameter = %s", toJSON(t, p))
+			}
+		})
+	}
+
+	// openapi2 has no validation, the specification is validated once converted back
+	doc3, err := openapi2conv.ToV3(&doc2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc3.Validate(context.Background()); err != nil {
+		t.Errorf("validate: %v", err)
+	}
+}
+
+// assertSwagger2Refs checks that every reference of spec points to a definition, parameter or response of doc2.
+func assertSwagger2Refs(t *testing.T, doc2 *openapi2.T, spec []byte) {
+	t.Helper()
+	var node interface{}
+	if err := json.Unmarshal(spec, &node); err != nil {
+		t.Fatal(err)
+	}
+	var refs []string
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch node := node.(type) {
+		case map[string]interface{}:
+			for key, value := range node {
+				if ref, ok := value.(string); ok && key == "$ref" {
+					refs = append(refs, ref)
+				}
+				walk(value)
+			}
+		case []interface{}:
+			for _, item := range node {
+				walk(item)
+			}
+		}
+	}
+	walk(node)
+	for _, ref := range refs {
+		var ok bool
+		switch {
+		case strings.HasPrefix(ref, "#/definitions/"):
+			_, ok = doc2.Definitions[strings.TrimPrefix(ref, "#/definitions/")]
+		case strings.HasPrefix(ref, "#/parameters/"):
+			_, ok = doc2.Parameters[strings.TrimPrefix(ref, "#/parameters/")]
+		case strings.HasPrefix(ref, "#/responses/"):
+			_, ok = doc2.Responses[strings.TrimPrefix(ref, "#/responses/")]
+		}
+		if !ok {
+			t.Errorf("dangling reference %s", ref)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package soda
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Router registers operations, it is implemented by Soda and Group.
+type Router interface {
+	Handle(path, method string, handlers ...fiber.Handler) *Operation
+}
+
+// TypedHandler handles a request with its bound parameters and request body,
+// the returned response is encoded by Respond.
+type TypedHandler[P, B, R any] func(c *fiber.Ctx, parameters *P, body *B) (*R, error)
+
+// Get registers a GET operation documented from the parameters type P and the response type R.
+// Use struct{} as P when the operation has no parameters.
+func Get[P, R any](r Router, path string, handler func(c *fiber.Ctx, parameters *P) (*R, error)) *Operation {
+	op := Handle(r, path, "GET", withoutBody[P, R](handler))
+	op.handler = handler
+	return op
+}
+
+// Delete registers a DELETE operation documented from the parameters type P and the response type R.
+func Delete[P, R any](r Router, path string, handler func(c *fiber.Ctx, parameters *P) (*R, error)) *Operation {
+	op := Handle(r, path, "DELETE", withoutBody[P, R](handler))
+	op.handler = handler
+	return op
+}
+
+// Post registers a POST operation documented from the parameters type P, the JSON request body type B and the response type R.
+func Post[P, B, R any](r Router, path string, handler TypedHandler[P, B, R]) *Operation {
+	return Handle(r, path, "POST", handler)
+}
+
+// Put registers a PUT operation documented from the parameters type P, the JSON request body type B and the response type R.
+func Put[P, B, R any](r Router, path string, handler TypedHandler[P, B, R]) *Operation {
+	return Handle(r, path, "PUT", handler)
+}
+
+// Patch registers a PATCH operation documented from the parameters type P, the JSON request body type B and the response type R.
+func Patch[P, B, R any](r Router, path string, handler TypedHandler[P, B, R]) *Operation {
+	return Handle(r, path, "PATCH", handler)
+}
+
+// Handle registers an operation whose parameters, request body and response are described by P, B and R.
+// Parameters and request body are bound by BindData and handed to the handler,
+// the response is sent by Respond with the success status, 200 unless SetSuccessStatus changes it.
+// Empty structs such as struct{} are not documented.
+func Handle[P, B, R any](r Router, path, method string, handler TypedHandler[P, B, R]) *Operation {
+	var op *Operation
+	op = r.Handle(path, method, func(c *fiber.Ctx) error {
+		parameters, err := typedLocal[P](c, KeyParameter)
+		if err != nil {
+			return err
+		}
+		body, err := typedLocal[B](c, KeyRequestBody)
+		if err != nil {
+			return err
+		}
+		resp, err := handler(c, parameters, body)
+		if err != nil {
+			return err
+		}
+		if resp == nil {
+			return c.SendStatus(op.successStatus)
+		}
+		return Respond(c, op.successStatus, resp)
+	})
+
+	op.handler = handler
+	op.successStatus = http.StatusOK
+	op.successModel = new(R)
+
+	if t := reflect.TypeOf((*P)(nil)).Elem(); !isEmptyStruct(t) {
+		op.SetParameters(*new(P))
+	}
+	if t := reflect.TypeOf((*B)(nil)).Elem(); !isEmptyStruct(t) {
+		op.SetJSONRequestBody(*new(B))
+	}
+	return op
+}
+
+// typedLocal returns the value of type T bound by BindData under key,
+// empty structs are never bound and are handed to the handler as zero values.
+func typedLocal[T any](c *fiber.Ctx, key string) (*T, error) {
+	if v, ok := c.Locals(key).(*T); ok {
+		return v, nil
+	}
+	if isEmptyStruct(reflect.TypeOf((*T)(nil)).Elem()) {
+		return new(T), nil
+	}
+	return nil, fmt.Errorf("%s of type %s is not bound to the request", key, reflect.TypeOf((*T)(nil)).Elem())
+}
+
+func withoutBody[P, R any](handler func(c *fiber.Ctx, parameters *P) (*R, error)) TypedHandler[P, struct{}, R] {
+	return func(c *fiber.Ctx, parameters *P, _ *struct{}) (*R, error) {
+		return handler(c, parameters)
+	}
+}
+
+func isEmptyStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.NumField() == 0
+}
@@ -0,0 +1,114 @@
+package soda
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type typedParameters struct {
+	ID int `path:"id"`
+}
+
+type typedBody struct {
+	Name string `json:"name"`
+}
+
+type typedUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestTypedHandlers(t *testing.T) {
+	app := New("test", "1.0.0")
+	mustRegister(t, Get(app, "/users", func(c *fiber.Ctx, _ *struct{}) (*[]typedUser, error) {
+		return &[]typedUser{{ID: 1, Name: "neo"}}, nil
+	}))
+	mustRegister(t, Post(app, "/users/:id", func(c *fiber.Ctx, p *typedParameters, b *typedBody) (*typedUser, error) {
+		return &typedUser{ID: p.ID, Name: b.Name}, nil
+	}).SetSuccessStatus(fiber.StatusCreated))
+	mustRegister(t, Delete(app, "/users/:id", func(c *fiber.Ctx, p *typedParameters) (*struct{}, error) {
+		return nil, nil
+	}).SetSuccessStatus(fiber.StatusNoContent))
+	mustRegister(t, Put(app, "/users/:id", func(c *fiber.Ctx, p *typedParameters, b *typedBody) (*typedUser, error) {
+		return nil, fiber.NewError(fiber.StatusConflict, "conflict")
+	}))
+
+	tests := []struct {
+		method, target, body string
+		status               int
+		want                 string
+	}{
+		{"GET", "/users", "", 200, `[{"id":1,"name":"neo"}]`},
+		{"POST", "/users/7", `{"name":"trinity"}`, 201, `{"id":7,"name":"trinity"}`},
+		{"DELETE", "/users/7", "", 204, ""},
+		{"PUT", "/users/7", `{"name":"trinity"}`, 409, "conflict"},
+	}
+	for _, tt := range tests {
+		resp := doRequest(t, app, tt.method, tt.target, tt.body, fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if resp.status != tt.status || strings.TrimSpace(resp.body) != tt.want {
+			t.Errorf("%s %s = %d %s, want %d %s", tt.method, tt.target, resp.status, resp.body, tt.status, tt.want)
+		}
+	}
+}
+
+func TestTypedHandlerDocumentsSuccessStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		missing int
+	}{
+		{"default", 200, 201},
+		{"created", 201, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New("test", "1.0.0")
+			op := Post(app, "/users/:id", func(c *fiber.Ctx, p *typedParameters, b *typedBody) (*typedUser, error) {
+				return nil, nil
+			})
+			if tt.status != 200 {
+				op.SetSuccessStatus(tt.status)
+			}
+			mustRegister(t, op)
+			resp := op.Operation.Responses.Get(tt.status)
+			if resp == nil || resp.Value.Content.Get(fiber.MIMEApplicationJSON) == nil {
+				t.Fatalf("the %d response is not documented", tt.status)
+			}
+			if op.Operation.Responses.Get(tt.missing) != nil {
+				t.Errorf("the %d response is documented", tt.missing)
+			}
+			if op.Operation.RequestBody == nil || op.Operation.Parameters.GetByInAndName("path", "id") == nil {
+				t.Error("the parameters and the request body are not documented")
+			}
+		})
+	}
+}
+
+func TestSetSuccessStatusRequiresTypedHandler(t *testing.T) {
+	app := New("test", "1.0.0")
+	err := app.Post("/users", func(c *fiber.Ctx) error { return nil }).SetSuccessStatus(201).Register()
+	if err == nil || !strings.Contains(err.Error(), "typed handlers") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestTypedHandlerFailsWithoutBoundInput(t *testing.T) {
+	app := New("test", "1.0.0")
+	op := Post(app, "/users/:id", func(c *fiber.Ctx, p *typedParameters, b *typedBody) (*typedUser, error) {
+		t.Error("the handler is called without its parameters")
+		return nil, nil
+	})
+	// the handler is mounted without BindData
+	raw := fiber.New()
+	raw.Post("/users/:id", op.handlers[len(op.handlers)-1])
+	resp, err := raw.Test(httptest.NewRequest("POST", "/users/1", strings.NewReader(`{}`)), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", resp.StatusCode)
+	}
+}
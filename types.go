@@ -0,0 +1,177 @@
+package soda
+
+import (
+	"database/sql"
+	"encoding"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// TypeDecoder decodes the text of a parameter into a value of the type it is registered for.
+type TypeDecoder func(value string) (interface{}, error)
+
+// typeSchemas are the schemas registered by RegisterTypeSchema, they are registered before the operations.
+var typeSchemas = map[reflect.Type]*openapi3.Schema{
+	timeType:                         openapi3.NewDateTimeSchema(),
+	uriType:                          openapi3.NewStringSchema().WithFormat("uri"),
+	fileHeaderType:                   openapi3.NewStringSchema().WithFormat("binary"),
+	reflect.TypeOf(time.Duration(0)): {Type: openapi3.TypeInteger, Format: "int64", Description: "duration in nanoseconds"},
+	reflect.TypeOf(big.Int{}):        openapi3.NewIntegerSchema(),
+	reflect.TypeOf(net.IP{}):         {Type: openapi3.TypeString, AnyOf: ipSchemas()},
+	reflect.TypeOf(netip.Addr{}):     {Type: openapi3.TypeString, AnyOf: ipSchemas()},
+}
+
+// ipSchemas are the schemas of the addresses of both IP versions.
+func ipSchemas() openapi3.SchemaRefs {
+	return openapi3.SchemaRefs{
+		openapi3.NewStringSchema().WithFormat("ipv4").NewRef(),
+		openapi3.NewStringSchema().WithFormat("ipv6").NewRef(),
+	}
+}
+
+// nullSchemas document the sql.Null* types in parameters, which are decoded by their Scan method, as nullable values.
+// encoding/json encodes them as structs such as {"String": "", "Valid": false}, so bodies document their fields.
+var nullSchemas = map[reflect.Type]*openapi3.Schema{
+	reflect.TypeOf(sql.NullString{}):  openapi3.NewStringSchema().WithNullable(),
+	reflect.TypeOf(sql.NullBool{}):    openapi3.NewBoolSchema().WithNullable(),
+	reflect.TypeOf(sql.NullByte{}):    openapi3.NewIntegerSchema().WithMin(0).WithMax(255).WithNullable(),
+	reflect.TypeOf(sql.NullInt16{}):   openapi3.NewIntegerSchema().WithMin(-1 << 15).WithMax(1<<15 - 1).WithNullable(),
+	reflect.TypeOf(sql.NullInt32{}):   openapi3.NewInt32Schema().WithNullable(),
+	reflect.TypeOf(sql.NullInt64{}):   openapi3.NewInt64Schema().WithNullable(),
+	reflect.TypeOf(sql.NullFloat64{}): openapi3.NewFloat64Schema().WithFormat("double").WithNullable(),
+	reflect.TypeOf(sql.NullTime{}):    openapi3.NewDateTimeSchema().WithNullable(),
+}
+
+// typeDecoders are the parameter decoders registered by RegisterTypeDecoder.
+var typeDecoders = map[reflect.Type]TypeDecoder{
+	uriType: func(value string) (interface{}, error) {
+		u, err := url.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		return *u, nil
+	},
+	reflect.TypeOf(sql.NullString{}):  scannerDecoder(reflect.TypeOf(sql.NullString{})),
+	reflect.TypeOf(sql.NullBool{}):    scannerDecoder(reflect.TypeOf(sql.NullBool{})),
+	reflect.TypeOf(sql.NullByte{}):    scannerDecoder(reflect.TypeOf(sql.NullByte{})),
+	reflect.TypeOf(sql.NullInt16{}):   scannerDecoder(reflect.TypeOf(sql.NullInt16{})),
+	reflect.TypeOf(sql.NullInt32{}):   scannerDecoder(reflect.TypeOf(sql.NullInt32{})),
+	reflect.TypeOf(sql.NullInt64{}):   scannerDecoder(reflect.TypeOf(sql.NullInt64{})),
+	reflect.TypeOf(sql.NullFloat64{}): scannerDecoder(reflect.TypeOf(sql.NullFloat64{})),
+	reflect.TypeOf(sql.NullTime{}): func(value string) (interface{}, error) {
+		if value == "" {
+			return sql.NullTime{}, nil
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, err
+		}
+		return sql.NullTime{Time: t, Valid: true}, nil
+	},
+}
+
+// scannerDecoder decodes parameters into the sql.Scanner t, empty values are null.
+func scannerDecoder(t reflect.Type) TypeDecoder {
+	return func(value string) (interface{}, error) {
+		v := reflect.New(t)
+		if value != "" {
+			if err := v.Interface().(sql.Scanner).Scan(value); err != nil {
+				return nil, err
+			}
+		}
+		return v.Elem().Interface(), nil
+	}
+}
+
+// RegisterTypeSchema documents the values of t with schema, instead of the schema generated from its kind,
+// such as RegisterTypeSchema(reflect.TypeOf(uuid.UUID{}), openapi3.NewUUIDSchema()).
+// Types implementing encoding.TextMarshaler are documented as strings unless their schema is registered.
+// Schemas of common standard library types, such as time.Time, big.Int and net.IP, are registered already.
+func RegisterTypeSchema(t reflect.Type, schema *openapi3.Schema) {
+	if t == nil || schema == nil {
+		panic("soda: RegisterTypeSchema expects a type and a schema")
+	}
+	typeSchemas[indirectType(t)] = schema
+}
+
+// RegisterTypeDecoder decodes the parameters of type t with decode, which returns a value of type t.
+// Types implementing encoding.TextUnmarshaler are decoded by UnmarshalText unless their decoder is registered.
+func RegisterTypeDecoder(t reflect.Type, decode TypeDecoder) {
+	if t == nil || decode == nil {
+		panic("soda: RegisterTypeDecoder expects a type and a decoder")
+	}
+	typeDecoders[indirectType(t)] = decode
+}
+
+// registeredSchema returns a copy of the schema registered for t, or of the schema of text marshalers.
+func registeredSchema(t reflect.Type) (*openapi3.Schema, bool) {
+	if schema, ok := typeSchemas[t]; ok {
+		// a copy, so that the props of a field do not change the registered schema
+		s := *schema
+		return &s, true
+	}
+	// interfaces are encoded as the values they hold
+	if t.Kind() != reflect.Interface && reflect.PtrTo(t).Implements(textMarshalerType) {
+		return openapi3.NewStringSchema(), true
+	}
+	return nil, false
+}
+
+// parameterSchema returns a copy of the schema of the sql.Null* parameter type t, unless another schema is registered for t.
+func parameterSchema(t reflect.Type) (*openapi3.Schema, bool) {
+	t = indirectType(t)
+	if _, ok := typeSchemas[t]; ok {
+		return nil, false
+	}
+	schema, ok := nullSchemas[t]
+	if !ok {
+		return nil, false
+	}
+	s := *schema
+	return &s, true
+}
+
+// registeredShape returns the shape of the values of t if its decoder or its schema is registered.
+func registeredShape(t reflect.Type) (int, bool) {
+	if _, ok := typeDecoders[t]; ok {
+		return shapePrimitive, true
+	}
+	schema, ok := typeSchemas[t]
+	if !ok {
+		return 0, false
+	}
+	switch schema.Type {
+	case openapi3.TypeArray:
+		return shapeArray, true
+	case openapi3.TypeObject:
+		return shapeObject, true
+	}
+	return shapePrimitive, true
+}
+
+// decodeRegisteredType decodes value into v with the decoder registered for its type, ok tells if there is one.
+func decodeRegisteredType(v reflect.Value, value string) (ok bool, err error) {
+	decode, ok := typeDecoders[v.Type()]
+	if !ok {
+		return false, nil
+	}
+	decoded, err := decode(value)
+	if err != nil {
+		return true, err
+	}
+	dv := reflect.ValueOf(decoded)
+	if !dv.IsValid() || !dv.Type().AssignableTo(v.Type()) {
+		return true, fmt.Errorf("the decoder of %v returned %T", v.Type(), decoded)
+	}
+	v.Set(dv)
+	return true, nil
+}
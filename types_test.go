@@ -0,0 +1,139 @@
+package soda
+
+import (
+	"database/sql"
+	"math/big"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gofiber/fiber/v2"
+)
+
+type stdlibBody struct {
+	IP       net.IP         `json:"ip"`
+	Addr     netip.Addr     `json:"addr"`
+	Duration time.Duration  `json:"duration"`
+	Count    big.Int        `json:"count"`
+	Name     sql.NullString `json:"name"`
+	Since    sql.NullTime   `json:"since"`
+}
+
+type stdlibParameters struct {
+	Name  sql.NullString `query:"name"`
+	Age   sql.NullInt64  `query:"age"`
+	Since sql.NullTime   `query:"since"`
+	IP    net.IP         `query:"ip"`
+}
+
+func TestTypeSchemas(t *testing.T) {
+	schema := generateSchema(t, stdlibBody{})
+	tests := []struct {
+		name string
+		prop string
+		want string
+	}{
+		{"net.IP", "ip", `{"anyOf":[{"format":"ipv4","type":"string"},{"format":"ipv6","type":"string"}],"type":"string"}`},
+		{"netip.Addr", "addr", `{"anyOf":[{"format":"ipv4","type":"string"},{"format":"ipv6","type":"string"}],"type":"string"}`},
+		{"time.Duration", "duration", `{"description":"duration in nanoseconds","format":"int64","type":"integer"}`},
+		{"big.Int", "count", `{"type":"integer"}`},
+		// encoding/json encodes the sql.Null* types as structs
+		{"sql.NullString", "name",
+			`{"additionalProperties":false,"properties":{"String":{"type":"string"},"Valid":{"type":"boolean"}},"required":["String","Valid"],"type":"object"}`},
+		{"sql.NullTime", "since",
+			`{"additionalProperties":false,"properties":{"Time":{"format":"date-time","type":"string"},"Valid":{"type":"boolean"}},"required":["Time","Valid"],"type":"object"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toJSON(t, schema.Properties[tt.prop]); got != tt.want {
+				t.Errorf("schema = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPSchemas(t *testing.T) {
+	// the formats are checked by format-aware validators, such as the ones of kin-openapi once defined
+	openapi3.DefineIPv4Format()
+	openapi3.DefineIPv6Format()
+	defer delete(openapi3.SchemaStringFormats, "ipv4")
+	defer delete(openapi3.SchemaStringFormats, "ipv6")
+
+	schema := generateSchema(t, stdlibBody{})
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"10.0.0.1", true},
+		{"2001:db8::1", true},
+		{"localhost", false},
+	}
+	for _, prop := range []string{"ip", "addr"} {
+		for _, tt := range tests {
+			t.Run(prop+" "+tt.value, func(t *testing.T) {
+				if err := schema.Properties[prop].Value.VisitJSON(tt.value); (err == nil) != tt.valid {
+					t.Errorf("VisitJSON = %v, want valid %v", err, tt.valid)
+				}
+			})
+		}
+	}
+}
+
+func TestParameterTypeSchemas(t *testing.T) {
+	g := newGenerator(&openapi3.Info{}, nil)
+	parameters := g.GenerateParameters(reflect.TypeOf(stdlibParameters{}))
+	if errs := g.takeErrors(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"name", `{"nullable":true,"type":"string"}`},
+		{"age", `{"format":"int64","nullable":true,"type":"integer"}`},
+		{"since", `{"format":"date-time","nullable":true,"type":"string"}`},
+		{"ip", `{"anyOf":[{"format":"ipv4","type":"string"},{"format":"ipv6","type":"string"}],"type":"string"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param := parameters.GetByInAndName(openapi3.ParameterInQuery, tt.name)
+			if param == nil {
+				t.Fatalf("parameter %s is not documented", tt.name)
+			}
+			if got := toJSON(t, param.Schema); got != tt.want {
+				t.Errorf("schema = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParameterTypeDecoders(t *testing.T) {
+	app := New("test", "1.0.0")
+	mustRegister(t, app.Get("/users", func(c *fiber.Ctx) error {
+		return c.JSON(c.Locals(KeyParameter))
+	}).SetParameters(stdlibParameters{}))
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+		want   string
+	}{
+		{"values", "/users?name=neo&age=3&since=2022-01-02T03:04:05Z&ip=10.0.0.1", 200,
+			`{"Name":{"String":"neo","Valid":true},"Age":{"Int64":3,"Valid":true},"Since":{"Time":"2022-01-02T03:04:05Z","Valid":true},"IP":"10.0.0.1"}`},
+		{"nulls", "/users?name=&age=", 200,
+			`{"Name":{"String":"","Valid":false},"Age":{"Int64":0,"Valid":false},"Since":{"Time":"0001-01-01T00:00:00Z","Valid":false},"IP":""}`},
+		{"invalid", "/users?age=a", 400, `[{"field":"age","in":"query","message":"invalid value, expected sql.NullInt64"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, app, "GET", tt.target, "")
+			if resp.status != tt.status || resp.body != tt.want {
+				t.Errorf("response = %d %s, want %d %s", resp.status, resp.body, tt.status, tt.want)
+			}
+		})
+	}
+}
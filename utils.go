@@ -74,3 +74,12 @@ func toCamelCase(str string) string {
 	kebab := strings.ReplaceAll(str, "-", " ")
 	return strings.ReplaceAll(cases.Title(language.English).String(kebab), " ", "")
 }
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,348 @@
+package soda
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gorilla/schema"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// positions of validation errors which are not parameters.
+const (
+	PositionBody     = "body"
+	PositionResponse = "response"
+)
+
+// ResponseValidationMode decides what happens to responses which do not match the specification.
+type ResponseValidationMode int
+
+const (
+	// ResponseValidationLog logs the problems.
+	ResponseValidationLog ResponseValidationMode = iota + 1
+	// ResponseValidationWarn logs the problems and adds a Warning header to the response.
+	ResponseValidationWarn
+	// ResponseValidationFail logs the problems and replaces the response with a 500 listing them.
+	ResponseValidationFail
+)
+
+// ValidationErrorRenderer answers requests whose parameters or body are invalid.
+type ValidationErrorRenderer func(c *fiber.Ctx, status int, errs ValidationErrors) error
+
+// RenderValidationErrors is the default ValidationErrorRenderer, it answers the list of errors as JSON.
+func RenderValidationErrors(c *fiber.Ctx, status int, errs ValidationErrors) error {
+	return c.Status(status).JSON(errs)
+}
+
+// requestValidationInput adapts the fiber request to the input of openapi3filter.
+func (op *Operation) requestValidationInput(c *fiber.Ctx) (*openapi3filter.RequestValidationInput, error) {
+	req := new(http.Request)
+	if err := fasthttpadaptor.ConvertRequest(c.Context(), req, true); err != nil {
+		return nil, err
+	}
+	pathParams := make(map[string]string, len(c.Route().Params))
+	for _, k := range c.Route().Params {
+		pathParams[k] = c.Params(k)
+	}
+	path := fixPath(op.Path)
+	// bodies which openapi3filter cannot decode, such as XML, are only checked by binding
+	contentType := mediaType(req.Header.Get(fiber.HeaderContentType))
+	return &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams,
+		Route: &routers.Route{
+			Spec:      op.Soda.oaiGenerator.openapi,
+			Path:      path,
+			PathItem:  op.Soda.oaiGenerator.openapi.Paths[path],
+			Method:    op.Method,
+			Operation: op.Operation,
+		},
+		Options: &openapi3filter.Options{
+			MultiError: true,
+			// security requirements are checked by the security handlers
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			ExcludeRequestBody: contentType != "" && openapi3filter.RegisteredBodyDecoder(contentType) == nil,
+		},
+	}, nil
+}
+
+// validateRequest validates the request against the operation's OpenAPI specification.
+func (op *Operation) validateRequest(c *fiber.Ctx) ValidationErrors {
+	input, err := op.requestValidationInput(c)
+	if err != nil {
+		return ValidationErrors{NewValidationError("", "", err.Error())}
+	}
+	if err := openapi3filter.ValidateRequest(c.Context(), input); err != nil {
+		errs := convertRequestError(err)
+		// the properties of a schema are checked in random order
+		sort.SliceStable(errs, func(i, j int) bool {
+			if errs[i].Position != errs[j].Position {
+				return errs[i].Position < errs[j].Position
+			}
+			return errs[i].Field < errs[j].Field
+		})
+		return errs
+	}
+	return nil
+}
+
+// validateResponse validates the status code and the body of the response
+// against the operation's OpenAPI specification.
+func (op *Operation) validateResponse(c *fiber.Ctx) ValidationErrors {
+	status := c.Response().StatusCode()
+	response := op.Operation.Responses.Get(status)
+	if response == nil {
+		response = op.declaredDefault()
+	}
+	if response == nil {
+		return ValidationErrors{NewValidationError(PositionResponse, "", fmt.Sprintf("status %d is not documented", status))}
+	}
+	input, err := op.requestValidationInput(c)
+	if err != nil {
+		return ValidationErrors{NewValidationError(PositionResponse, "", err.Error())}
+	}
+	header := make(http.Header)
+	c.Response().Header.VisitAll(func(key, val []byte) {
+		header.Add(string(key), string(val))
+	})
+	// bodies documented without a schema, such as html pages, and bodies which openapi3filter cannot decode are not validated
+	contentType := string(c.Response().Header.ContentType())
+	if documented, mt := documentedContent(response.Value.Content, contentType); mt != nil {
+		input.Options.ExcludeResponseBody = mt.Schema == nil || mt.Schema.Value == nil || mt.Schema.Value.IsEmpty() ||
+			openapi3filter.RegisteredBodyDecoder(mediaType(contentType)) == nil
+		header.Set(fiber.HeaderContentType, documented)
+	}
+	responseInput := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 status,
+		Header:                 header,
+		Options:                input.Options,
+	}
+	responseInput.SetBodyBytes(c.Response().Body())
+	if err := openapi3filter.ValidateResponse(c.Context(), responseInput); err != nil {
+		return convertResponseError(err)
+	}
+	return nil
+}
+
+// declaredDefault returns the default response of the operation,
+// or nil if it is the placeholder which soda documents when the operation declares none.
+func (op *Operation) declaredDefault() *openapi3.ResponseRef {
+	response := op.Operation.Responses.Default()
+	if response == nil || response.Value == op.placeholder {
+		return nil
+	}
+	return response
+}
+
+// documentedContent finds the media type of content documenting contentType and returns its key,
+// parameters such as the charset are ignored unless a media type matches them exactly.
+func documentedContent(content openapi3.Content, contentType string) (string, *openapi3.MediaType) {
+	if mt := content.Get(contentType); mt != nil {
+		return contentType, mt
+	}
+	for _, key := range sortedKeys(content) {
+		if mediaType(key) == mediaType(contentType) {
+			return key, content[key]
+		}
+	}
+	return contentType, nil
+}
+
+// checkResponse handles the problems of the response according to mode.
+func (op *Operation) checkResponse(c *fiber.Ctx, mode ResponseValidationMode) {
+	errs := op.validateResponse(c)
+	if len(errs) == 0 {
+		return
+	}
+	log.Printf("soda: response of %s %s does not match the specification: %v", op.Method, fixPath(op.Path), errs)
+	switch mode {
+	case ResponseValidationWarn:
+		c.Set(fiber.HeaderWarning, `199 soda "response does not match the specification"`)
+	case ResponseValidationFail:
+		c.Response().Reset()
+		if op.Soda.Options.problemDetails {
+			problem := NewProblem(fiber.StatusInternalServerError, "response does not match the specification")
+			_ = problem.WithExtension("errors", errs).Render(c)
+			return
+		}
+		_ = c.Status(fiber.StatusInternalServerError).JSON(errs)
+	}
+}
+
+func convertResponseError(err error) ValidationErrors {
+	var e *openapi3filter.ResponseError
+	if errors.As(err, &e) {
+		if e.Err == nil {
+			return ValidationErrors{NewValidationError(PositionResponse, "", e.Reason)}
+		}
+		return convertSchemaError(PositionResponse, "", e.Err)
+	}
+	return ValidationErrors{NewValidationError(PositionResponse, "", err.Error())}
+}
+
+func convertRequestError(err error) ValidationErrors {
+	var errs ValidationErrors
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, inner := range e {
+			errs = append(errs, convertRequestError(inner)...)
+		}
+	case *openapi3filter.RequestError:
+		position, field := PositionBody, ""
+		if e.Parameter != nil {
+			position, field = e.Parameter.In, e.Parameter.Name
+		}
+		if e.Err == nil {
+			return ValidationErrors{NewValidationError(position, field, e.Reason)}
+		}
+		errs = append(errs, convertSchemaError(position, field, e.Err)...)
+	default:
+		errs = append(errs, NewValidationError("", "", err.Error()))
+	}
+	return errs
+}
+
+func convertSchemaError(position, field string, err error) ValidationErrors {
+	var errs ValidationErrors
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, inner := range e {
+			errs = append(errs, convertSchemaError(position, field, inner)...)
+		}
+	case *openapi3.SchemaError:
+		path := append([]string{field}, e.JSONPointer()...)
+		reason := e.Reason
+		if reason == "" {
+			reason = fmt.Sprintf("does not match %q", e.SchemaField)
+		}
+		errs = append(errs, NewValidationError(position, strings.Trim(strings.Join(path, "."), "."), reason))
+	default:
+		errs = append(errs, NewValidationError(position, field, err.Error()))
+	}
+	return errs
+}
+
+// convertDecodeError converts the errors of the parameters decoder, whose keys are already wire names.
+func convertDecodeError(position string, err error) ValidationErrors {
+	me, ok := err.(schema.MultiError)
+	if !ok {
+		return ValidationErrors{NewValidationError(position, "", err.Error())}
+	}
+	keys := make([]string, 0, len(me))
+	for k := range me {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	errs := make(ValidationErrors, 0, len(me))
+	for _, k := range keys {
+		switch e := me[k].(type) {
+		case schema.ConversionError:
+			field := e.Key
+			if e.Index >= 0 {
+				field = fmt.Sprintf("%s.%d", e.Key, e.Index)
+			}
+			errs = append(errs, NewValidationError(position, field, fmt.Sprintf("invalid value, expected %s", e.Type)))
+		case schema.EmptyFieldError:
+			errs = append(errs, NewValidationError(position, e.Key, "is required"))
+		default:
+			errs = append(errs, NewValidationError(position, k, e.Error()))
+		}
+	}
+	return errs
+}
+
+// convertBodyError converts the errors of the request body parser.
+func convertBodyError(err error) error {
+	var (
+		typeErr   *json.UnmarshalTypeError
+		syntaxErr *json.SyntaxError
+		fiberErr  *fiber.Error
+		errs      ValidationErrors
+	)
+	switch {
+	case errors.As(err, &errs):
+		return errs
+	case errors.As(err, &typeErr):
+		return ValidationErrors{NewValidationError(PositionBody, typeErr.Field, fmt.Sprintf("invalid value, expected %s", typeErr.Type))}
+	case errors.As(err, &syntaxErr):
+		return ValidationErrors{NewValidationError(PositionBody, "", syntaxErr.Error())}
+	case errors.As(err, &fiberErr):
+		return err
+	}
+	if _, ok := err.(schema.MultiError); ok {
+		return convertDecodeError(PositionBody, err)
+	}
+	return ValidationErrors{NewValidationError(PositionBody, "", err.Error())}
+}
+
+// convertValidatorError converts the errors of the validator, renaming the fields of t after their wire names.
+// The wire name is taken from the first of tags found on the field, which is also its position unless position is set.
+func convertValidatorError(t reflect.Type, position string, err error, tags ...string) error {
+	fieldErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+	errs := make(ValidationErrors, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		tag, field := wireField(t, fe.StructNamespace(), tags...)
+		if position != "" {
+			tag = position
+		}
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+		errs = append(errs, NewValidationError(tag, field, fmt.Sprintf("does not satisfy %q", rule)))
+	}
+	return errs
+}
+
+// wireField translates the validator namespace of a field of t, such as "Parameters.Items[0].Name",
+// into its wire name, it also returns which of tags named the field.
+func wireField(t reflect.Type, namespace string, tags ...string) (tag, name string) {
+	segments := strings.Split(namespace, ".")
+	names := make([]string, 0, len(segments))
+	for _, segment := range segments[1:] {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		fieldName, index := segment, ""
+		if i := strings.Index(segment, "["); i >= 0 {
+			fieldName, index = segment[:i], segment[i:]
+		}
+		if t.Kind() != reflect.Struct {
+			names = append(names, segment)
+			continue
+		}
+		f, ok := t.FieldByName(fieldName)
+		if !ok {
+			names = append(names, segment)
+			continue
+		}
+		t = f.Type
+		// fields of embedded structs are promoted
+		if f.Anonymous {
+			continue
+		}
+		for _, tagName := range tags {
+			if v := f.Tag.Get(tagName); v != "" {
+				tag, fieldName = tagName, strings.Split(v, ",")[0]
+				break
+			}
+		}
+		names = append(names, fieldName+index)
+	}
+	return tag, strings.Join(names, ".")
+}
@@ -0,0 +1,327 @@
+package soda
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type oaiParameters struct {
+	ID    int    `path:"id" oai:"maximum=100"`
+	Sort  string `query:"sort" oai:"enum=asc,desc"`
+	Trace string `header:"X-Trace" oai:"pattern=^[a-f0-9]+$"`
+}
+
+type oaiBody struct {
+	Name  string   `json:"name" oai:"maxLength=5"`
+	Items []string `json:"items" oai:"minItems=1"`
+}
+
+func decodeValidationErrors(t *testing.T, body string) ValidationErrors {
+	t.Helper()
+	var errs ValidationErrors
+	if err := json.Unmarshal([]byte(body), &errs); err != nil {
+		t.Fatalf("%s: %v", body, err)
+	}
+	return errs
+}
+
+func TestOpenAPIRequestValidation(t *testing.T) {
+	app := New("test", "1.0.0", EnableOpenAPIRequestValidation())
+	mustRegister(t, app.Post("/items/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	}).SetParameters(oaiParameters{}).SetJSONRequestBody(oaiBody{}))
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		trace  string
+		status int
+		want   ValidationErrors
+	}{
+		{"valid", "/items/1?sort=asc", `{"name":"neo","items":["a"]}`, "ab12", 204, nil},
+		{"path maximum", "/items/101?sort=asc", `{"name":"neo","items":["a"]}`, "ab12", 400, ValidationErrors{
+			NewValidationError("path", "id", "number must be at most 100"),
+		}},
+		{"query enum", "/items/1?sort=up", `{"name":"neo","items":["a"]}`, "ab12", 400, ValidationErrors{
+			NewValidationError("query", "sort", "value is not one of the allowed values"),
+		}},
+		{"header pattern", "/items/1?sort=asc", `{"name":"neo","items":["a"]}`, "xyz", 400, ValidationErrors{
+			NewValidationError("header", "X-Trace", `string doesn't match the regular expression "^[a-f0-9]+$"`),
+		}},
+		{"body", "/items/1?sort=asc", `{"name":"trinity","items":[]}`, "ab12", 400, ValidationErrors{
+			NewValidationError("body", "items", "minimum number of items is 1"),
+			NewValidationError("body", "name", "maximum string length is 5"),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := doRequest(t, app, "POST", tt.target, tt.body,
+				fiber.HeaderContentType, fiber.MIMEApplicationJSON, "X-Trace", tt.trace)
+			if resp.status != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.status, tt.status, resp.body)
+			}
+			if tt.want == nil {
+				return
+			}
+			if got := decodeValidationErrors(t, resp.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %s, want %s", toJSON(t, got), toJSON(t, tt.want))
+			}
+		})
+	}
+}
+
+func TestOpenAPIRequestValidationIsOptional(t *testing.T) {
+	app := New("test", "1.0.0")
+	mustRegister(t, app.Get("/items/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	}).SetParameters(oaiParameters{}))
+	// the constraints only documented by oai tags are not enforced
+	if resp := doRequest(t, app, "GET", "/items/101?sort=up", ""); resp.status != fiber.StatusNoContent {
+		t.Errorf("status = %d: %s", resp.status, resp.body)
+	}
+}
+
+type validatedUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name" oai:"maxLength=5"`
+}
+
+func TestResponseValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    ResponseValidationMode
+		code    int
+		user    validatedUser
+		status  int
+		warning bool
+	}{
+		{"valid", ResponseValidationFail, 200, validatedUser{ID: 1, Name: "neo"}, 200, false},
+		{"log", ResponseValidationLog, 200, validatedUser{ID: 1, Name: "trinity"}, 200, false},
+		{"warn", ResponseValidationWarn, 200, validatedUser{ID: 1, Name: "trinity"}, 200, true},
+		{"fail", ResponseValidationFail, 200, validatedUser{ID: 1, Name: "trinity"}, 500, false},
+		// the default response soda documents for operations without responses is not a declared response
+		{"undocumented status", ResponseValidationWarn, fiber.StatusTeapot, validatedUser{ID: 1, Name: "neo"}, fiber.StatusTeapot, true},
+		{"undocumented status fails", ResponseValidationFail, fiber.StatusTeapot, validatedUser{ID: 1, Name: "neo"}, 500, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New("test", "1.0.0", EnableResponseValidation(tt.mode))
+			mustRegister(t, app.Get("/user", func(c *fiber.Ctx) error {
+				return c.Status(tt.code).JSON(tt.user)
+			}).AddJSONResponse(200, validatedUser{}))
+			resp := doRequest(t, app, "GET", "/user", "")
+			if resp.status != tt.status {
+				t.Errorf("status = %d, want %d: %s", resp.status, tt.status, resp.body)
+			}
+			if got := resp.header.Get(fiber.HeaderWarning) != ""; got != tt.warning {
+				t.Errorf("warning = %v, want %v", got, tt.warning)
+			}
+		})
+	}
+}
+
+func TestResponseValidationErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		problems bool
+		// defaultModel declares the default response
+		defaultModel interface{}
+		handler      fiber.Handler
+		want         ValidationErrors
+	}{
+		{"undocumented status", false, nil, func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusAccepted).JSON(validatedUser{})
+		}, ValidationErrors{NewValidationError(PositionResponse, "", "status 202 is not documented")}},
+		{"status documented by the default response", false, validatedUser{}, func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusAccepted).JSON(validatedUser{})
+		}, nil},
+		{"body validated by the default response", false, validatedUser{}, func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusAccepted).JSON(validatedUser{Name: "trinity"})
+		}, ValidationErrors{NewValidationError(PositionResponse, "name", "maximum string length is 5")}},
+		{"invalid body", false, nil, func(c *fiber.Ctx) error {
+			return c.JSON(validatedUser{Name: "trinity"})
+		}, ValidationErrors{NewValidationError(PositionResponse, "name", "maximum string length is 5")}},
+		{"problem documented by the default response", true, nil, func(c *fiber.Ctx) error {
+			return NewProblem(fiber.StatusNotFound, "no such user").Render(c)
+		}, nil},
+		{"content type not documented by the default response", true, nil, func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusAccepted).JSON(validatedUser{})
+		}, ValidationErrors{NewValidationError(PositionResponse, "", `response header Content-Type has unexpected value: "application/json"`)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var options []Option
+			if tt.problems {
+				options = append(options, EnableProblemDetails())
+			}
+			app := New("test", "1.0.0", options...)
+			op := app.Get("/user", tt.handler).AddJSONResponse(200, validatedUser{})
+			if tt.defaultModel != nil {
+				op.AddJSONResponse(0, tt.defaultModel)
+			}
+			mustRegister(t, op)
+			// the response is validated as if it was answered by op
+			var got ValidationErrors
+			app.App.Get("/check", func(c *fiber.Ctx) error {
+				if err := tt.handler(c); err != nil {
+					return err
+				}
+				got = op.validateResponse(c)
+				return nil
+			})
+			doRequest(t, app, "GET", "/check", "")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %s, want %s", toJSON(t, got), toJSON(t, tt.want))
+			}
+		})
+	}
+}
+
+func TestResponseValidationOfDocumentationPages(t *testing.T) {
+	app := New("test", "1.0.0", WithSwagger("/swagger"), WithRedoc("/redoc"), WithRapiDoc("/rapidoc"),
+		EnableResponseValidation(ResponseValidationFail))
+	for _, path := range []string{"/swagger", "/redoc", "/rapidoc"} {
+		resp := doRequest(t, app, "GET", path, "")
+		if resp.status != fiber.StatusOK || resp.header.Get(fiber.HeaderWarning) != "" {
+			t.Errorf("GET %s = %d: %s", path, resp.status, resp.body)
+		}
+		if ct := resp.header.Get(fiber.HeaderContentType); ct != fiber.MIMETextHTML {
+			t.Errorf("GET %s content type = %q", path, ct)
+		}
+	}
+}
+
+type bindingParameters struct {
+	Page  int    `query:"page_no" validate:"min=1"`
+	Token string `header:"X-Token" validate:"required"`
+}
+
+type bindingItem struct {
+	Label string `json:"label" validate:"required"`
+}
+
+type bindingBody struct {
+	Name  string        `json:"full_name" validate:"max=5"`
+	Items []bindingItem `json:"items" validate:"dive"`
+}
+
+type customValidationError struct {
+	Count int `json:"count"`
+}
+
+func TestBindingValidationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		body    string
+		headers []string
+		want    ValidationErrors
+	}{
+		{"validator", "/items?page_no=0", `{"full_name":"trinity","items":[{"label":""}]}`, nil, ValidationErrors{
+			NewValidationError("query", "page_no", `does not satisfy "min=1"`),
+			NewValidationError("header", "X-Token", `does not satisfy "required"`),
+		}},
+		{"decoder", "/items?page_no=first", `{}`, []string{"X-Token", "t"}, ValidationErrors{
+			NewValidationError("query", "page_no", "invalid value, expected int"),
+		}},
+		{"body validator", "/items?page_no=1", `{"full_name":"trinity","items":[{"label":""}]}`, []string{"X-Token", "t"}, ValidationErrors{
+			NewValidationError("body", "full_name", `does not satisfy "max=5"`),
+			NewValidationError("body", "items[0].label", `does not satisfy "required"`),
+		}},
+		{"body type", "/items?page_no=1", `{"full_name":1}`, []string{"X-Token", "t"}, ValidationErrors{
+			NewValidationError("body", "full_name", "invalid value, expected string"),
+		}},
+	}
+	app := New("test", "1.0.0", EnableValidateRequest())
+	mustRegister(t, app.Post("/items", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	}).SetParameters(bindingParameters{}).SetJSONRequestBody(bindingBody{}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := append([]string{fiber.HeaderContentType, fiber.MIMEApplicationJSON}, tt.headers...)
+			resp := doRequest(t, app, "POST", tt.target, tt.body, headers...)
+			if resp.status != fiber.StatusBadRequest {
+				t.Fatalf("status = %d: %s", resp.status, resp.body)
+			}
+			if got := decodeValidationErrors(t, resp.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %s, want %s", toJSON(t, got), toJSON(t, tt.want))
+			}
+		})
+	}
+}
+
+func TestValidationErrorResponse(t *testing.T) {
+	renderer := func(c *fiber.Ctx, status int, errs ValidationErrors) error {
+		return c.Status(status).JSON(customValidationError{Count: len(errs)})
+	}
+	tests := []struct {
+		name    string
+		options []Option
+		status  int
+		body    string
+		schema  string
+	}{
+		{"default", nil, 400, "", "sodaValidationErrors"},
+		{"status", []Option{WithValidationErrorStatus(422)}, 422, "", "sodaValidationErrors"},
+		{"renderer", []Option{WithValidationErrorRenderer(renderer, customValidationError{})}, 400, `{"count":1}`, "sodaCustomValidationError"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New("test", "1.0.0", append(tt.options, EnableValidateRequest())...)
+			bound := mustRegister(t, app.Get("/items", func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusNoContent)
+			}).SetParameters(bindingParameters{}))
+			unbound := mustRegister(t, app.Get("/health", func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusNoContent)
+			}))
+
+			resp := doRequest(t, app, "GET", "/items?page_no=1", "")
+			if resp.status != tt.status {
+				t.Errorf("status = %d, want %d", resp.status, tt.status)
+			}
+			if tt.body != "" && resp.body != tt.body {
+				t.Errorf("body = %s, want %s", resp.body, tt.body)
+			}
+			documented := bound.Operation.Responses.Get(tt.status)
+			if documented == nil || documented.Ref != "#/components/responses/"+validationErrorResponseName {
+				t.Fatalf("the %d response is not documented", tt.status)
+			}
+			ref := documented.Value.Content.Get(fiber.MIMEApplicationJSON).Schema.Ref
+			if ref != "#/components/schemas/"+tt.schema {
+				t.Errorf("schema = %s, want %s", ref, tt.schema)
+			}
+			if unbound.Operation.Responses.Get(tt.status) != nil {
+				t.Error("the validation error response is documented by an operation without input")
+			}
+		})
+	}
+}
+
+func TestWireField(t *testing.T) {
+	tests := []struct {
+		namespace string
+		tags      []string
+		tag, name string
+	}{
+		{"bindingParameters.Page", parameterPositions, "query", "page_no"},
+		{"bindingParameters.Token", parameterPositions, "header", "X-Token"},
+		{"bindingBody.Name", []string{"json"}, "json", "full_name"},
+		{"bindingBody.Items[2].Label", []string{"json"}, "json", "items[2].label"},
+		{"bindingBody.Unknown", []string{"json"}, "", "Unknown"},
+	}
+	for _, tt := range tests {
+		model := reflect.TypeOf(bindingParameters{})
+		if strings.HasPrefix(tt.namespace, "bindingBody") {
+			model = reflect.TypeOf(bindingBody{})
+		}
+		tag, name := wireField(model, tt.namespace, tt.tags...)
+		if tag != tt.tag || name != tt.name {
+			t.Errorf("wireField(%q) = %q, %q, want %q, %q", tt.namespace, tag, name, tt.tag, tt.name)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package soda
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Versions of the OpenAPI specification rendered by soda.
+const (
+	OpenAPIVersion30 = "3.0.3"
+	OpenAPIVersion31 = "3.1.0"
+)
+
+// webhooksExtension holds the webhooks in OpenAPI 3.0, which has no webhooks.
+const webhooksExtension = "x-webhooks"
+
+// WithOpenAPIVersion renders the specification in OpenAPI 3.0, OpenAPIVersion30 by default, or 3.1.
+// Other versions are reported by Build and fall back to 3.0.
+// Soda.OpenAPI returns the 3.0 model either way, it is converted when the specification is rendered.
+func WithOpenAPIVersion(version string) Option {
+	return func(o *Options) {
+		o.openAPIVersion = version
+	}
+}
+
+// AddWebhook documents a webhook, which is a request sent by the API to its users, whose JSON body is described by model.
+// Webhooks are rendered as "webhooks" in OpenAPI 3.1 and as "x-webhooks" in 3.0.
+// The returned operation may be further described.
+func (s *Soda) AddWebhook(name, method string, model interface{}) *openapi3.Operation {
+	g := s.oaiGenerator
+	operation := &openapi3.Operation{Responses: openapi3.Responses{}}
+	operation.OperationID = "webhook-" + name
+	operation.RequestBody = g.GenerateJSONRequestBody(operation.OperationID, reflect.TypeOf(model))
+	operation.AddResponse(http.StatusOK, openapi3.NewResponse().WithDescription("the webhook is received"))
+	for _, err := range g.takeErrors() {
+		err.Position = "webhook " + name
+		s.errs = append(s.errs, err)
+	}
+
+	webhooks, _ := g.openapi.Extensions[webhooksExtension].(map[string]*openapi3.PathItem)
+	if webhooks == nil {
+		webhooks = make(map[string]*openapi3.PathItem)
+		if g.openapi.Extensions == nil {
+			g.openapi.Extensions = make(map[string]interface{})
+		}
+		g.openapi.Extensions[webhooksExtension] = webhooks
+	}
+	if webhooks[name] == nil {
+		webhooks[name] = &openapi3.PathItem{}
+	}
+	webhooks[name].SetOperation(method, operation)
+	return operation
+}
+
+// renderSpec renders the specification in the configured version of OpenAPI.
+func (s *Soda) renderSpec() ([]byte, error) {
+	spec, err := s.oaiGenerator.openapi.MarshalJSON()
+	if err != nil || s.Options.openAPIVersion != OpenAPIVersion31 {
+		return spec, err
+	}
+	return convertTo31(spec)
+}
+
+// convertTo31 converts a rendered OpenAPI 3.0 specification into OpenAPI 3.1.
+func convertTo31(spec []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+	doc["openapi"] = OpenAPIVersion31
+	if webhooks, ok := doc[webhooksExtension]; ok {
+		doc["webhooks"] = webhooks
+		delete(doc, webhooksExtension)
+	}
+	convertNode(doc)
+	return json.Marshal(doc)
+}
+
+// convertNode converts the schemas found in a node of the specification which is not a schema.
+func convertNode(node interface{}) {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		for key, value := range node {
+			switch key {
+			case "schema":
+				convertSchema(value)
+			case "schemas":
+				if schemas, ok := value.(map[string]interface{}); ok {
+					for _, schema := range schemas {
+						convertSchema(schema)
+					}
+				}
+			case "example", "examples":
+				// values, which are not part of the specification
+			default:
+				convertNode(value)
+			}
+		}
+	case []interface{}:
+		for _, item := range node {
+			convertNode(item)
+		}
+	}
+}
+
+// convertSchema converts an OpenAPI 3.0 schema into a JSON Schema 2020-12 one:
+// nullable types become type arrays, examples become arrays, single values enums become consts
+// and boolean exclusive bounds become numeric ones.
+func convertSchema(node interface{}) {
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, key := range []string{"items", "not", "additionalProperties"} {
+		convertSchema(schema[key])
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		if schemas, ok := schema[key].([]interface{}); ok {
+			for _, s := range schemas {
+				convertSchema(s)
+			}
+		}
+	}
+	if props, ok := schema["properties"].(map[string]interface{}); ok {
+		for _, prop := range props {
+			convertSchema(prop)
+		}
+	}
+
+	if example, ok := schema["example"]; ok {
+		schema["examples"] = []interface{}{example}
+		delete(schema, "example")
+	}
+	for exclusive, bound := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+		if v, ok := schema[exclusive].(bool); ok {
+			if value, ok := schema[bound]; ok && v {
+				schema[exclusive] = value
+				delete(schema, bound)
+			} else {
+				delete(schema, exclusive)
+			}
+		}
+	}
+	// null is added to the enum of nullable schemas before single values enums become consts
+	nullable, _ := schema["nullable"].(bool)
+	delete(schema, "nullable")
+	if nullable {
+		convertNullable(schema)
+	}
+	convertEnum(schema)
+}
+
+// convertEnum turns the enum of schema into a const if it has a single value.
+func convertEnum(schema map[string]interface{}) {
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) == 1 {
+		schema["const"] = enum[0]
+		delete(schema, "enum")
+	}
+}
+
+// convertNullable adds null to the types of schema, a schema without type is wrapped into an anyOf.
+func convertNullable(schema map[string]interface{}) {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []interface{}{typ, "null"}
+		if enum, ok := schema["enum"].([]interface{}); ok {
+			schema["enum"] = append(enum, nil)
+		}
+		return
+	}
+	nullSchema := map[string]interface{}{"type": "null"}
+	// the nullable references generated by soda
+	if allOf, ok := schema["allOf"].([]interface{}); ok && len(allOf) == 1 && len(schema) == 1 {
+		schema["anyOf"] = []interface{}{allOf[0], nullSchema}
+		delete(schema, "allOf")
+		return
+	}
+	inner := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		inner[key] = value
+		delete(schema, key)
+	}
+	convertEnum(inner)
+	schema["anyOf"] = []interface{}{inner, nullSchema}
+}
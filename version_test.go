@@ -0,0 +1,82 @@
+package soda
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestConvertSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{"nullable", `{"type":"string","nullable":true}`, `{"type":["string","null"]}`},
+		{"not nullable", `{"type":"string","nullable":false}`, `{"type":"string"}`},
+		{"enum", `{"type":"string","enum":["a","b"]}`, `{"enum":["a","b"],"type":"string"}`},
+		{"single value enum", `{"type":"string","enum":["a"]}`, `{"const":"a","type":"string"}`},
+		{"nullable enum", `{"type":"string","enum":["a","b"],"nullable":true}`, `{"enum":["a","b",null],"type":["string","null"]}`},
+		{"nullable single value enum", `{"type":"string","enum":["a"],"nullable":true}`, `{"enum":["a",null],"type":["string","null"]}`},
+		{"nullable untyped enum", `{"enum":["a"],"nullable":true}`, `{"anyOf":[{"const":"a"},{"type":"null"}]}`},
+		{"nullable reference", `{"allOf":[{"$ref":"#/components/schemas/User"}],"nullable":true}`,
+			`{"anyOf":[{"$ref":"#/components/schemas/User"},{"type":"null"}]}`},
+		{"example", `{"type":"integer","example":1}`, `{"examples":[1],"type":"integer"}`},
+		{"exclusive bounds", `{"type":"integer","minimum":1,"exclusiveMinimum":true,"maximum":9,"exclusiveMaximum":false}`,
+			`{"exclusiveMinimum":1,"maximum":9,"type":"integer"}`},
+		{"nested", `{"type":"object","properties":{"tags":{"type":"array","items":{"type":"string","nullable":true}}},` +
+			`"additionalProperties":{"type":"integer","enum":[1]}}`,
+			`{"additionalProperties":{"const":1,"type":"integer"},"properties":{"tags":{"items":{"type":["string","null"]},"type":"array"}},"type":"object"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.schema), &schema); err != nil {
+				t.Fatal(err)
+			}
+			convertSchema(schema)
+			if got := toJSON(t, schema); got != tt.want {
+				t.Errorf("schema = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type versionBody struct {
+	Role  string  `json:"role" oai:"enum=admin;nullable"`
+	Level *int    `json:"level" oai:"example=3"`
+	Note  *string `json:"note"`
+}
+
+func TestOpenAPIVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    []string
+		wantErr string
+	}{
+		{"3.0", OpenAPIVersion30, []string{`"openapi":"3.0.3"`, `"x-webhooks":{"created"`, `"enum":["admin"]`, `"nullable":true`}, ""},
+		{"3.1", OpenAPIVersion31, []string{`"openapi":"3.1.0"`, `"webhooks":{"created"`, `"enum":["admin",null]`,
+			`"type":["string","null"]`, `"examples":[3]`}, ""},
+		{"unsupported", "2.0", []string{`"openapi":"3.0.3"`, `"x-webhooks":{"created"`},
+			`WithOpenAPIVersion is invalid, cause of unsupported version "2.0", OpenAPI 3.0.3 is rendered instead`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New("test", "1.0.0", WithOpenAPIVersion(tt.version))
+			mustRegister(t, app.Post("/users", func(c *fiber.Ctx) error { return nil }).SetJSONRequestBody(versionBody{}))
+			app.AddWebhook("created", fiber.MethodPost, versionBody{})
+			if err := app.Build(); (err != nil || tt.wantErr != "") && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Build() = %v, want %s", err, tt.wantErr)
+			}
+			spec := app.spec
+			for _, want := range tt.want {
+				if !strings.Contains(string(spec), want) {
+					t.Errorf("spec = %s, want %s", spec, want)
+				}
+			}
+		})
+	}
+}